@@ -0,0 +1,56 @@
+package steam_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Acidic9/steam"
+)
+
+// TestConcurrentUse uses an Account from several goroutines while it logs out
+// and in again. It is meant to be run with the race detector.
+func TestConcurrentUse(t *testing.T) {
+	srv := newServer(t)
+	acc, _ := login(t, srv)
+	acc.OnRelogin = nil // logging out makes relogins fail or repeat
+
+	ctx, cancel := context.WithCancel(context.Background())
+	listening := make(chan error, 1)
+	go func() {
+		listening <- acc.ListenAndServeContext(ctx, func(steam.SteamID64, string) {})
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				// Broadcasts racing with Logout may fail; the race
+				// detector and the final Broadcast check the Account.
+				acc.Broadcast("hello")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 3; j++ {
+				time.Sleep(5 * time.Millisecond)
+				acc.Logout()
+				acc.Session()
+				acc.MachineAuth()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := acc.Broadcast("hello"); err != nil {
+		t.Errorf("Broadcast after the concurrent use: %v", err)
+	}
+	cancel()
+	if err := <-listening; !errors.Is(err, context.Canceled) {
+		t.Errorf("ListenAndServeContext returned %v, want context.Canceled", err)
+	}
+}
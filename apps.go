@@ -1,13 +1,10 @@
 package steam
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"context"
 	"net/url"
 	"strconv"
 	"sync"
-	"time"
 )
 
 type AppNews []struct {
@@ -32,30 +29,43 @@ type AppList []struct {
 	Name  string
 }
 
+// playerCountWorkers is the number of concurrent requests made by
+// GetNumberOfCurrentPlayersForAllApps.
+const playerCountWorkers = 8
+
 type AppInfo struct {
 	Appid       int
 	Name        string
 	Playercount int
 }
 
+// GetNewsForApp is a wrapper around DefaultClient.GetNewsForApp.
+func GetNewsForApp(appid, count, maxLength int) (AppNews, error) {
+	return DefaultClient.GetNewsForApp(appid, count, maxLength)
+}
+
+// GetNewsForAppContext is a wrapper around DefaultClient.GetNewsForAppContext.
+func GetNewsForAppContext(ctx context.Context, appid, count, maxLength int) (AppNews, error) {
+	return DefaultClient.GetNewsForAppContext(ctx, appid, count, maxLength)
+}
+
 // GetNewsForApp returns a type AppNews containing all the news for a specific AppID in order from most recent.
 // The count parameter specific how many news items to return.
 // The maxLength parameter is used to specify how many characters of each news item to show.
 // If 0 is used for maxLength then there will be no limit on how many characters to return.
-func GetNewsForApp(appid, count, maxLength int) (AppNews, error) {
+func (c *Client) GetNewsForApp(appid, count, maxLength int) (AppNews, error) {
+	return c.GetNewsForAppContext(context.Background(), appid, count, maxLength)
+}
+
+// GetNewsForAppContext is like GetNewsForApp but its requests are bound to ctx.
+func (c *Client) GetNewsForAppContext(ctx context.Context, appid, count, maxLength int) (AppNews, error) {
 	var news AppNews
 
-	resp, err := http.Get("http://api.steampowered.com/ISteamNews/GetNewsForApp/v0002/?" + url.Values{
+	content, err := c.get(ctx, c.apiURL("ISteamNews/GetNewsForApp/v0002/", url.Values{
 		"appid":     {strconv.FormatInt(int64(appid), 10)},
 		"count":     {strconv.FormatInt(int64(count), 10)},
 		"maxlength": {strconv.FormatInt(int64(maxLength), 10)},
-	}.Encode())
-	if err != nil {
-		return news, err
-	}
-	defer resp.Body.Close()
-
-	content, err := ioutil.ReadAll(resp.Body)
+	}))
 	if err != nil {
 		return news, err
 	}
@@ -77,10 +87,7 @@ func GetNewsForApp(appid, count, maxLength int) (AppNews, error) {
 		}
 	}
 
-	if err := json.Unmarshal(content, &newsForAppResponse); err != nil {
-		if err.Error() == "invalid character '<' looking for beginning of value" {
-			return news, jsonUnmarshallErrorCheck(content)
-		}
+	if err := unmarshalJSON(content, &newsForAppResponse); err != nil {
 		return news, err
 	}
 
@@ -88,20 +95,30 @@ func GetNewsForApp(appid, count, maxLength int) (AppNews, error) {
 	return news, nil
 }
 
+// GetGlobalAchievementPercentagesForApp is a wrapper around
+// DefaultClient.GetGlobalAchievementPercentagesForApp.
+func GetGlobalAchievementPercentagesForApp(appid int) (GlobalAchievementPercentage, error) {
+	return DefaultClient.GetGlobalAchievementPercentagesForApp(appid)
+}
+
+// GetGlobalAchievementPercentagesForAppContext is a wrapper around DefaultClient.GetGlobalAchievementPercentagesForAppContext.
+func GetGlobalAchievementPercentagesForAppContext(ctx context.Context, appid int) (GlobalAchievementPercentage, error) {
+	return DefaultClient.GetGlobalAchievementPercentagesForAppContext(ctx, appid)
+}
+
 // GetGlobalAchievementPercentagesForApp returns a type GlobalAchievementPercentage containing all existing achievements
 // on the Steam network and their global achieved percentage for an AppID.
-func GetGlobalAchievementPercentagesForApp(appid int) (GlobalAchievementPercentage, error) {
+func (c *Client) GetGlobalAchievementPercentagesForApp(appid int) (GlobalAchievementPercentage, error) {
+	return c.GetGlobalAchievementPercentagesForAppContext(context.Background(), appid)
+}
+
+// GetGlobalAchievementPercentagesForAppContext is like GetGlobalAchievementPercentagesForApp but its requests are bound to ctx.
+func (c *Client) GetGlobalAchievementPercentagesForAppContext(ctx context.Context, appid int) (GlobalAchievementPercentage, error) {
 	var achievements GlobalAchievementPercentage
 
-	resp, err := http.Get("http://api.steampowered.com/ISteamUserStats/GetGlobalAchievementPercentagesForApp/v0002/?" + url.Values{
+	content, err := c.get(ctx, c.apiURL("ISteamUserStats/GetGlobalAchievementPercentagesForApp/v0002/", url.Values{
 		"gameid": {strconv.FormatInt(int64(appid), 10)},
-	}.Encode())
-	if err != nil {
-		return achievements, err
-	}
-	defer resp.Body.Close()
-
-	content, err := ioutil.ReadAll(resp.Body)
+	}))
 	if err != nil {
 		return achievements, err
 	}
@@ -115,10 +132,7 @@ func GetGlobalAchievementPercentagesForApp(appid int) (GlobalAchievementPercenta
 		}
 	}
 
-	if err := json.Unmarshal(content, &globalAchievementPercentagesForAppResponse); err != nil {
-		if err.Error() == "invalid character '<' looking for beginning of value" {
-			return achievements, jsonUnmarshallErrorCheck(content)
-		}
+	if err := unmarshalJSON(content, &globalAchievementPercentagesForAppResponse); err != nil {
 		return achievements, err
 	}
 
@@ -126,17 +140,26 @@ func GetGlobalAchievementPercentagesForApp(appid int) (GlobalAchievementPercenta
 	return achievements, nil
 }
 
-// GetAppList returns a type AppList containing all existing AppID's on the Steam network.
+// GetAppList is a wrapper around DefaultClient.GetAppList.
 func GetAppList() (AppList, error) {
-	var appList AppList
+	return DefaultClient.GetAppList()
+}
 
-	resp, err := http.Get("https://api.steampowered.com/ISteamApps/GetAppList/v1")
-	if err != nil {
-		return appList, err
-	}
-	defer resp.Body.Close()
+// GetAppListContext is a wrapper around DefaultClient.GetAppListContext.
+func GetAppListContext(ctx context.Context) (AppList, error) {
+	return DefaultClient.GetAppListContext(ctx)
+}
+
+// GetAppList returns a type AppList containing all existing AppID's on the Steam network.
+func (c *Client) GetAppList() (AppList, error) {
+	return c.GetAppListContext(context.Background())
+}
 
-	content, err := ioutil.ReadAll(resp.Body)
+// GetAppListContext is like GetAppList but its requests are bound to ctx.
+func (c *Client) GetAppListContext(ctx context.Context) (AppList, error) {
+	var appList AppList
+
+	content, err := c.get(ctx, c.apiURL("ISteamApps/GetAppList/v1", nil))
 	if err != nil {
 		return appList, err
 	}
@@ -152,10 +175,7 @@ func GetAppList() (AppList, error) {
 		}
 	}
 
-	if err := json.Unmarshal(content, &appListResponse); err != nil {
-		if err.Error() == "invalid character '<' looking for beginning of value" {
-			return appList, jsonUnmarshallErrorCheck(content)
-		}
+	if err := unmarshalJSON(content, &appListResponse); err != nil {
 		return appList, err
 	}
 
@@ -163,18 +183,27 @@ func GetAppList() (AppList, error) {
 	return appList, nil
 }
 
+// GetNumberOfCurrentPlayers is a wrapper around DefaultClient.GetNumberOfCurrentPlayers.
+func GetNumberOfCurrentPlayers(appid int) (int, error) {
+	return DefaultClient.GetNumberOfCurrentPlayers(appid)
+}
+
+// GetNumberOfCurrentPlayersContext is a wrapper around DefaultClient.GetNumberOfCurrentPlayersContext.
+func GetNumberOfCurrentPlayersContext(ctx context.Context, appid int) (int, error) {
+	return DefaultClient.GetNumberOfCurrentPlayersContext(ctx, appid)
+}
+
 // GetNumberOfCurrentPlayers returns the number of players which are playing a
 // specified AppID open.
-func GetNumberOfCurrentPlayers(appid int) (int, error) {
-	resp, err := http.Get("https://api.steampowered.com/ISteamUserStats/GetNumberOfCurrentPlayers/v1?" + url.Values{
-		"appid": {strconv.FormatInt(int64(appid), 10)},
-	}.Encode())
-	if err != nil {
-		return 0, err
-	}
-	defer resp.Body.Close()
+func (c *Client) GetNumberOfCurrentPlayers(appid int) (int, error) {
+	return c.GetNumberOfCurrentPlayersContext(context.Background(), appid)
+}
 
-	content, err := ioutil.ReadAll(resp.Body)
+// GetNumberOfCurrentPlayersContext is like GetNumberOfCurrentPlayers but its requests are bound to ctx.
+func (c *Client) GetNumberOfCurrentPlayersContext(ctx context.Context, appid int) (int, error) {
+	content, err := c.get(ctx, c.apiURL("ISteamUserStats/GetNumberOfCurrentPlayers/v1", url.Values{
+		"appid": {strconv.FormatInt(int64(appid), 10)},
+	}))
 	if err != nil {
 		return 0, err
 	}
@@ -186,59 +215,84 @@ func GetNumberOfCurrentPlayers(appid int) (int, error) {
 		}
 	}
 
-	if err := json.Unmarshal(content, &numberOfCurrentPlayersResponse); err != nil {
-		if err.Error() == "invalid character '<' looking for beginning of value" {
-			return 0, jsonUnmarshallErrorCheck(content)
-		}
+	if err := unmarshalJSON(content, &numberOfCurrentPlayersResponse); err != nil {
 		return 0, err
 	}
 
-	if numberOfCurrentPlayersResponse.Response.Result != 1 {
-		return 0, err
+	if numberOfCurrentPlayersResponse.Response.Result != int(EResultOK) {
+		return 0, &ResultError{Result: EResult(numberOfCurrentPlayersResponse.Response.Result)}
 	}
 
 	return numberOfCurrentPlayersResponse.Response.Player_count, nil
 }
 
-// GetNumberOfCurrentPlayersForAllApps returns the number of players for all existing apps on the Steam network.
-// This function may take minutes to complete as it requests ~28000 http requests.
+// GetNumberOfCurrentPlayersForAllApps is a wrapper around
+// DefaultClient.GetNumberOfCurrentPlayersForAllApps.
 func GetNumberOfCurrentPlayersForAllApps() ([]AppInfo, error) {
-	appList, err := GetAppList()
+	return DefaultClient.GetNumberOfCurrentPlayersForAllApps()
+}
+
+// GetNumberOfCurrentPlayersForAllAppsContext is a wrapper around DefaultClient.GetNumberOfCurrentPlayersForAllAppsContext.
+func GetNumberOfCurrentPlayersForAllAppsContext(ctx context.Context) ([]AppInfo, error) {
+	return DefaultClient.GetNumberOfCurrentPlayersForAllAppsContext(ctx)
+}
+
+// GetNumberOfCurrentPlayersForAllApps returns the number of players for all existing apps on the Steam network.
+// This function may take minutes to complete as it requests ~28000 http requests,
+// paced by the Client's Limiter.
+func (c *Client) GetNumberOfCurrentPlayersForAllApps() ([]AppInfo, error) {
+	return c.GetNumberOfCurrentPlayersForAllAppsContext(context.Background())
+}
+
+// GetNumberOfCurrentPlayersForAllAppsContext is like GetNumberOfCurrentPlayersForAllApps but its requests are bound to ctx.
+func (c *Client) GetNumberOfCurrentPlayersForAllAppsContext(ctx context.Context) ([]AppInfo, error) {
+	appList, err := c.GetAppListContext(ctx)
 	if err != nil {
 		return []AppInfo{}, err
 	}
 
-	var que int
-	var queMax int = 800
 	var apps []AppInfo
+	var mu sync.Mutex
 
+	// A handful of workers is plenty, as the Client's Limiter decides how
+	// fast the requests are actually sent.
+	indexes := make(chan int)
 	var wg sync.WaitGroup
-	for _, a := range appList {
-		app := a
-		for que > queMax {
-			time.Sleep(time.Millisecond * 100)
-		}
-		que++
+	for i := 0; i < playerCountWorkers; i++ {
 		wg.Add(1)
 		go func() {
-			defer func() {
-				que--
-				wg.Done()
-			}()
-
-			currentPlayers, err := GetNumberOfCurrentPlayers(app.Appid)
-			if err != nil {
-				return
-			}
+			defer wg.Done()
+			for i := range indexes {
+				app := appList[i]
+				currentPlayers, err := c.GetNumberOfCurrentPlayersContext(ctx, app.Appid)
+				if err != nil {
+					continue
+				}
 
-			apps = append(apps, AppInfo{
-				Appid:       app.Appid,
-				Name:        app.Name,
-				Playercount: currentPlayers,
-			})
+				mu.Lock()
+				apps = append(apps, AppInfo{
+					Appid:       app.Appid,
+					Name:        app.Name,
+					Playercount: currentPlayers,
+				})
+				mu.Unlock()
+			}
 		}()
 	}
 
+queue:
+	for i := range appList {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break queue
+		}
+	}
+	close(indexes)
+
 	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return apps, err
+	}
 	return apps, nil
 }
@@ -0,0 +1,455 @@
+package steam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// ErrUsage can be returned by a CommandFunc when its arguments are invalid,
+// to make the Bot reply with the usage of the command.
+var ErrUsage = errors.New("steam: invalid command usage")
+
+// CommandFunc runs a command.
+type CommandFunc func(req *CommandRequest) error
+
+// Middleware wraps the CommandFunc of every command of a Bot, eg. to log or
+// filter the commands run.
+type Middleware func(next CommandFunc) CommandFunc
+
+// Command is a command of a Bot.
+type Command struct {
+	Name        string   // the name following the prefix, eg. "ping"
+	Aliases     []string // other names of the command
+	Usage       string   // the arguments shown in help, eg. "<steamid> [reason]"
+	Description string   // a short description shown in help
+
+	// MinArgs and MaxArgs bound the number of arguments. MaxArgs is not
+	// checked if zero.
+	MinArgs int
+	MaxArgs int
+
+	// AdminOnly restricts the command to the Admins of the Bot.
+	AdminOnly bool
+
+	// Cooldown is how long a user must wait between two uses of the command.
+	// Admins are not affected.
+	Cooldown time.Duration
+
+	Handler CommandFunc
+}
+
+// CommandRequest is a command sent to a Bot.
+type CommandRequest struct {
+	Bot     *Bot
+	Command *Command
+	Event   ChatEvent
+	From    SteamID64
+
+	Name    string   // the name the command was called with
+	Args    []string // the arguments, split on spaces unless quoted
+	RawArgs string   // the text following the name
+
+	ctx context.Context
+}
+
+// Context returns the context of the request.
+func (req *CommandRequest) Context() context.Context {
+	return req.ctx
+}
+
+// Arg returns the argument i of req, or an empty string if there are fewer
+// arguments.
+func (req *CommandRequest) Arg(i int) string {
+	if i < 0 || i >= len(req.Args) {
+		return ""
+	}
+	return req.Args[i]
+}
+
+// Reply sends text to the user who sent req.
+func (req *CommandRequest) Reply(text string) error {
+	return req.Bot.Account.MessageContext(req.ctx, req.From, text)
+}
+
+// Replyf is like Reply but formats its arguments as fmt.Sprintf does.
+func (req *CommandRequest) Replyf(format string, a ...interface{}) error {
+	return req.Reply(fmt.Sprintf(format, a...))
+}
+
+// Bot runs the commands sent to an Account in chat messages such as
+// "!kick 76561198132612090 spam". It is a ChatHandler, so it can be passed to
+// Account.Chat; Run does so.
+//
+// Each command runs on its own goroutine, so that slow commands and replies
+// do not hold up the chat; commands may therefore finish in any order. A
+// "help" command listing the others is added unless one is registered.
+type Bot struct {
+	Account *Account
+
+	// Prefixes are the prefixes of commands. If empty, "!" is used.
+	Prefixes []string
+
+	// Admins are the users allowed to run AdminOnly commands.
+	Admins []SteamID64
+
+	// ReplyUnknown makes the Bot reply to commands it does not know.
+	// Otherwise they are ignored, as they may be meant for another bot.
+	ReplyUnknown bool
+
+	// OnError, if set, is called with the errors returned by commands and
+	// replies. Otherwise they are ignored.
+	OnError func(req *CommandRequest, err error)
+
+	running sync.WaitGroup // the commands running
+
+	mu         sync.Mutex
+	commands   map[string]*Command // by name and alias
+	middleware []Middleware
+	lastUsed   map[cooldownKey]time.Time
+}
+
+// cooldownKey identifies the uses of a command by a user.
+type cooldownKey struct {
+	user    SteamID64
+	command string
+}
+
+// NewBot returns a Bot running commands sent to acc.
+func NewBot(acc *Account) *Bot {
+	return &Bot{Account: acc}
+}
+
+// Register adds cmd to b. It panics if cmd has no name or handler, or if one
+// of its names is already registered.
+func (b *Bot) Register(cmd Command) {
+	if cmd.Name == "" || cmd.Handler == nil {
+		panic("steam: command without name or handler")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.commands == nil {
+		b.commands = make(map[string]*Command)
+	}
+	for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+		if _, ok := b.commands[strings.ToLower(name)]; ok {
+			panic("steam: command " + name + " registered twice")
+		}
+	}
+	c := &cmd
+	for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+		b.commands[strings.ToLower(name)] = c
+	}
+}
+
+// HandleFunc registers a command named name which accepts any arguments.
+func (b *Bot) HandleFunc(name, description string, f CommandFunc) {
+	b.Register(Command{Name: name, Description: description, Handler: f})
+}
+
+// Use adds middleware to b. The first middleware added is the outermost.
+func (b *Bot) Use(middleware ...Middleware) {
+	b.mu.Lock()
+	b.middleware = append(b.middleware, middleware...)
+	b.mu.Unlock()
+}
+
+// IsAdmin reports whether user is one of the Admins of b.
+func (b *Bot) IsAdmin(user SteamID64) bool {
+	for _, admin := range b.Admins {
+		if admin == user {
+			return true
+		}
+	}
+	return false
+}
+
+// Run receives the chat events of the Account of b and runs the commands
+// sent until ctx is done, as Chat.Run does. It returns once the commands
+// running, whose context is ctx, have returned.
+func (b *Bot) Run(ctx context.Context) error {
+	err := b.Account.Chat(ChatHandlerFunc(func(e ChatEvent) {
+		b.handle(ctx, e)
+	})).Run(ctx)
+	b.running.Wait()
+	return err
+}
+
+// HandleChat starts the command in e, if any, on its own goroutine.
+func (b *Bot) HandleChat(e ChatEvent) {
+	b.handle(context.Background(), e)
+}
+
+// prefixes returns the command prefixes of b.
+func (b *Bot) prefixes() []string {
+	if len(b.Prefixes) == 0 {
+		return []string{"!"}
+	}
+	return b.Prefixes
+}
+
+// prefix returns the prefix shown in replies.
+func (b *Bot) prefix() string {
+	return b.prefixes()[0]
+}
+
+// handle starts the command in e, if any.
+func (b *Bot) handle(ctx context.Context, e ChatEvent) {
+	if e.Type != ChatMessage {
+		return
+	}
+	text := strings.TrimSpace(e.Text)
+	var line string
+	for _, prefix := range b.prefixes() {
+		if strings.HasPrefix(text, prefix) {
+			line = strings.TrimSpace(text[len(prefix):])
+			break
+		}
+	}
+	if line == "" {
+		return
+	}
+
+	name, rawArgs := line, ""
+	if i := strings.IndexFunc(line, unicode.IsSpace); i >= 0 {
+		name, rawArgs = line[:i], strings.TrimSpace(line[i:])
+	}
+	req := &CommandRequest{
+		Bot:     b,
+		Event:   e,
+		From:    e.From,
+		Name:    strings.ToLower(name),
+		Args:    parseArgs(rawArgs),
+		RawArgs: rawArgs,
+		ctx:     ctx,
+	}
+
+	b.running.Add(1)
+	go func() {
+		defer b.running.Done()
+		b.run(req)
+	}()
+}
+
+// run runs the command of req, replying to errors of usage.
+func (b *Bot) run(req *CommandRequest) {
+	cmd := b.command(req.Name)
+	if cmd == nil {
+		if b.ReplyUnknown {
+			b.reply(req, "Unknown command "+b.prefix()+req.Name+". Send "+b.prefix()+"help for the list of commands.")
+		}
+		return
+	}
+	req.Command = cmd
+
+	admin := b.IsAdmin(req.From)
+	if cmd.AdminOnly && !admin {
+		b.reply(req, "You are not allowed to use "+b.prefix()+cmd.Name+".")
+		return
+	}
+	if len(req.Args) < cmd.MinArgs || (cmd.MaxArgs > 0 && len(req.Args) > cmd.MaxArgs) {
+		b.reply(req, "Usage: "+b.usage(cmd))
+		return
+	}
+	if !admin {
+		wait := b.startCooldown(req.From, cmd)
+		if wait > 0 {
+			b.reply(req, fmt.Sprintf("Wait %v before using %s%s again.", (wait+time.Second-1).Truncate(time.Second), b.prefix(), cmd.Name))
+			return
+		}
+	}
+
+	err := b.chain(cmd.Handler)(req)
+	if err != nil && !admin {
+		// Only successful uses count.
+		b.cancelCooldown(req.From, cmd)
+	}
+	if errors.Is(err, ErrUsage) {
+		b.reply(req, "Usage: "+b.usage(cmd))
+		return
+	}
+	if err != nil && b.OnError != nil {
+		b.OnError(req, err)
+	}
+}
+
+// reply sends text to the sender of req, passing errors to OnError.
+func (b *Bot) reply(req *CommandRequest, text string) {
+	if err := req.Reply(text); err != nil && b.OnError != nil {
+		b.OnError(req, err)
+	}
+}
+
+// command returns the command called name, or nil. The help command is
+// built in unless one is registered.
+func (b *Bot) command(name string) *Command {
+	b.mu.Lock()
+	cmd := b.commands[name]
+	b.mu.Unlock()
+	if cmd == nil && name == "help" {
+		return b.helpCommand()
+	}
+	return cmd
+}
+
+// chain wraps f with the middleware of b.
+func (b *Bot) chain(f CommandFunc) CommandFunc {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := len(b.middleware) - 1; i >= 0; i-- {
+		f = b.middleware[i](f)
+	}
+	return f
+}
+
+// startCooldown returns how long user must wait before using cmd, and
+// otherwise records its use. The use is recorded before the command runs so
+// that the user cannot run it again meanwhile.
+func (b *Bot) startCooldown(user SteamID64, cmd *Command) time.Duration {
+	if cmd.Cooldown <= 0 {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	key := cooldownKey{user: user, command: strings.ToLower(cmd.Name)}
+	if last, ok := b.lastUsed[key]; ok && now.Sub(last) < cmd.Cooldown {
+		return cmd.Cooldown - now.Sub(last)
+	}
+
+	if b.lastUsed == nil {
+		b.lastUsed = make(map[cooldownKey]time.Time)
+	}
+	// Forget the uses which no longer matter.
+	for k, last := range b.lastUsed {
+		if c := b.commands[k.command]; c == nil || now.Sub(last) >= c.Cooldown {
+			delete(b.lastUsed, k)
+		}
+	}
+	b.lastUsed[key] = now
+	return 0
+}
+
+// cancelCooldown forgets the use of cmd by user recorded by startCooldown,
+// as the command failed.
+func (b *Bot) cancelCooldown(user SteamID64, cmd *Command) {
+	if cmd.Cooldown <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	delete(b.lastUsed, cooldownKey{user: user, command: strings.ToLower(cmd.Name)})
+	b.mu.Unlock()
+}
+
+// usage returns the usage line of cmd.
+func (b *Bot) usage(cmd *Command) string {
+	usage := b.prefix() + cmd.Name
+	if cmd.Usage != "" {
+		usage += " " + cmd.Usage
+	}
+	return usage
+}
+
+// helpCommand returns the built-in help command.
+func (b *Bot) helpCommand() *Command {
+	return &Command{
+		Name:        "help",
+		Usage:       "[command]",
+		Description: "Lists the commands, or describes one.",
+		MaxArgs:     1,
+		Handler: func(req *CommandRequest) error {
+			return req.Reply(b.Help(req.From, req.Arg(0)))
+		},
+	}
+}
+
+// Help returns the help text shown to user: the list of the commands they
+// can use, or the description of the command called name if it is not
+// empty.
+func (b *Bot) Help(user SteamID64, name string) string {
+	if name != "" {
+		name = strings.TrimPrefix(strings.ToLower(name), b.prefix())
+		cmd := b.command(name)
+		if cmd == nil || (cmd.AdminOnly && !b.IsAdmin(user)) {
+			return "Unknown command " + b.prefix() + name + "."
+		}
+		help := "Usage: " + b.usage(cmd)
+		if cmd.Description != "" {
+			help += "\n" + cmd.Description
+		}
+		if len(cmd.Aliases) > 0 {
+			help += "\nAliases: " + strings.Join(cmd.Aliases, ", ")
+		}
+		return help
+	}
+
+	b.mu.Lock()
+	commands := make([]*Command, 0, len(b.commands)+1)
+	seen := make(map[*Command]bool)
+	for _, cmd := range b.commands {
+		if !seen[cmd] && (!cmd.AdminOnly || b.IsAdmin(user)) {
+			seen[cmd] = true
+			commands = append(commands, cmd)
+		}
+	}
+	_, hasHelp := b.commands["help"]
+	b.mu.Unlock()
+	if !hasHelp {
+		commands = append(commands, b.helpCommand())
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+
+	lines := []string{"Commands:"}
+	for _, cmd := range commands {
+		line := b.usage(cmd)
+		if cmd.Description != "" {
+			line += " - " + cmd.Description
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseArgs splits s on spaces. Double quotes group words into a single
+// argument, and a backslash escapes the next character.
+func parseArgs(s string) []string {
+	var args []string
+	var arg strings.Builder
+	inArg, quoted, escaped := false, false, false
+	for _, r := range s {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped, inArg = true, true
+		case r == '"':
+			quoted, inArg = !quoted, true
+		case unicode.IsSpace(r) && !quoted:
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
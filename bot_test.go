@@ -0,0 +1,177 @@
+package steam_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Acidic9/steam"
+	"github.com/Acidic9/steam/steamtest"
+)
+
+// startBot runs bot on the chat of its Account, logged into srv, until the
+// test ends.
+func startBot(t *testing.T, bot *steam.Bot) {
+	serveChat(t, bot.Account, bot)
+}
+
+// send delivers text from user to the Account "bot" and waits for a reply
+// which starts with reply. It returns the messages sent since text was.
+func send(t *testing.T, srv *steamtest.Server, user steam.SteamID64, text, reply string) []steamtest.Message {
+	t.Helper()
+	before := len(srv.Messages())
+	srv.DeliverMessage(user, botID, text)
+	return waitReply(t, srv, before, user, reply)
+}
+
+// waitReply waits for the Account "bot" to send a message to user which
+// starts with text, after the first before messages. It returns the messages
+// sent after them so far.
+func waitReply(t *testing.T, srv *steamtest.Server, before int, user steam.SteamID64, text string) []steamtest.Message {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		messages := srv.Messages()[before:]
+		for _, m := range messages {
+			if m.From == botID && m.To == user && strings.HasPrefix(m.Text, text) {
+				return messages
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("no reply %q to %v; sent %+v", text, user, srv.Messages())
+	return nil
+}
+
+func TestBotCommands(t *testing.T) {
+	srv := newServer(t)
+	acc, _ := login(t, srv)
+	user, admin := friendIDs[0], friendIDs[1]
+
+	bot := steam.NewBot(acc)
+	bot.Admins = []steam.SteamID64{admin}
+	bot.HandleFunc("ping", "Answers pong.", func(req *steam.CommandRequest) error {
+		return req.Reply("pong")
+	})
+	bot.Register(steam.Command{
+		Name:    "echo",
+		Aliases: []string{"say"},
+		MinArgs: 1,
+		Usage:   "<text>...",
+		Handler: func(req *steam.CommandRequest) error {
+			return req.Reply("echo: " + strings.Join(req.Args, "|"))
+		},
+	})
+	bot.Register(steam.Command{
+		Name:      "kick",
+		AdminOnly: true,
+		Handler: func(req *steam.CommandRequest) error {
+			return req.Replyf("kicked %s", req.Arg(0))
+		},
+	})
+	startBot(t, bot)
+
+	for _, test := range []struct {
+		from  steam.SteamID64
+		text  string
+		reply string
+	}{
+		{user, "!ping", "pong"},
+		{user, `!SAY "a b" c`, "echo: a b|c"},
+		{user, "!echo", "Usage: !echo <text>..."},
+		{user, "!kick someone", "You are not allowed to use !kick."},
+		{admin, "!kick someone", "kicked someone"},
+		{user, "!help", "Commands:\n!echo <text>...\n!help [command]"},
+		{user, "!help ping", "Usage: !ping\nAnswers pong."},
+	} {
+		send(t, srv, test.from, test.text, test.reply)
+	}
+
+	// Unknown commands are ignored by default.
+	before := len(srv.Messages())
+	srv.DeliverMessage(user, botID, "!nope")
+	for _, m := range send(t, srv, user, "!ping", "pong") {
+		if strings.HasPrefix(m.Text, "Unknown command") {
+			t.Errorf("replied %q to an unknown command", m.Text)
+		}
+	}
+	if n := len(srv.Messages()) - before; n != 1 {
+		t.Errorf("%d replies to an unknown command and !ping, want 1", n)
+	}
+}
+
+func TestBotReplyUnknown(t *testing.T) {
+	srv := newServer(t)
+	acc, _ := login(t, srv)
+
+	bot := steam.NewBot(acc)
+	bot.Prefixes = []string{"/"}
+	bot.ReplyUnknown = true
+	startBot(t, bot)
+
+	send(t, srv, friendIDs[0], "/nope", "Unknown command /nope. Send /help for the list of commands.")
+}
+
+func TestBotConcurrentCommands(t *testing.T) {
+	srv := newServer(t)
+	acc, _ := login(t, srv)
+
+	bot := steam.NewBot(acc)
+	release := make(chan struct{})
+	bot.HandleFunc("slow", "", func(req *steam.CommandRequest) error {
+		select {
+		case <-release:
+		case <-req.Context().Done():
+		}
+		return req.Reply("slow done")
+	})
+	bot.HandleFunc("ping", "", func(req *steam.CommandRequest) error {
+		return req.Reply("pong")
+	})
+	startBot(t, bot)
+
+	// A command still running does not hold up the others.
+	srv.DeliverMessage(friendIDs[0], botID, "!slow")
+	send(t, srv, friendIDs[1], "!ping", "pong")
+	close(release)
+	waitReply(t, srv, 0, friendIDs[0], "slow done")
+}
+
+func TestBotCooldown(t *testing.T) {
+	srv := newServer(t)
+	acc, _ := login(t, srv)
+	user, admin := friendIDs[0], friendIDs[1]
+
+	bot := steam.NewBot(acc)
+	bot.Admins = []steam.SteamID64{admin}
+	bot.Register(steam.Command{
+		Name:     "roll",
+		Usage:    "<sides>",
+		Cooldown: time.Hour,
+		Handler: func(req *steam.CommandRequest) error {
+			if req.Arg(0) != "6" {
+				return steam.ErrUsage
+			}
+			return req.Reply("rolled 4")
+		},
+	})
+	startBot(t, bot)
+
+	for _, test := range []struct {
+		from  steam.SteamID64
+		text  string
+		reply string
+	}{
+		// A failed use does not start the cooldown.
+		{user, "!roll x", "Usage: !roll <sides>"},
+		{user, "!roll 6", "rolled 4"},
+		{user, "!roll 6", "Wait 1h0m0s before using !roll again."},
+		// Admins and other users are not affected.
+		{admin, "!roll 6", "rolled 4"},
+		{friendIDs[2], "!roll 6", "rolled 4"},
+	} {
+		if messages := send(t, srv, test.from, test.text, test.reply); len(messages) != 1 {
+			t.Errorf("%d replies to %q, want 1: %+v", len(messages), test.text, messages)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package steam
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheEntry is a response stored in a Cache.
+type CacheEntry struct {
+	Body         []byte
+	ETag         string    // the ETag header of the response, if any
+	LastModified string    // the Last-Modified header of the response, if any
+	Expires      time.Time // the time until which the response may be used without revalidation
+}
+
+// fresh reports whether e may be used without asking the server.
+func (e *CacheEntry) fresh(now time.Time) bool {
+	return now.Before(e.Expires)
+}
+
+// Cache stores the responses of read-only requests, keyed by their URL.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+}
+
+// DefaultCacheTTLs is used by a Client with a Cache and no CacheTTLs of its own.
+var DefaultCacheTTLs = map[string]time.Duration{
+	"ISteamApps/GetAppList":                                 time.Hour,
+	"ISteamNews/GetNewsForApp":                              10 * time.Minute,
+	"ISteamUserStats/GetGlobalAchievementPercentagesForApp": time.Hour,
+	"ISteamUser/ResolveVanityURL":                           10 * time.Minute,
+	"ISteamUser/GetPlayerSummaries":                         time.Minute,
+	"groups":                                                10 * time.Minute,
+}
+
+// cacheTTL returns how long a response for path stays fresh. The TTL of the
+// longest matching prefix of path, in whole segments, is used.
+func (c *Client) cacheTTL(path string) time.Duration {
+	ttls := c.CacheTTLs
+	if ttls == nil {
+		ttls = DefaultCacheTTLs
+	}
+
+	path = strings.Trim(path, "/")
+	for path != "" {
+		if ttl, ok := ttls[path]; ok {
+			return ttl
+		}
+		i := strings.LastIndex(path, "/")
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+	return c.DefaultCacheTTL
+}
+
+// getCached sends the GET request req through the Client's Cache. Fresh
+// responses are returned without a request, stale ones are revalidated with
+// If-None-Match or If-Modified-Since when possible.
+func (c *Client) getCached(req *http.Request) ([]byte, error) {
+	key := req.URL.String()
+	now := time.Now()
+
+	entry, ok := c.Cache.Get(key)
+	if ok && entry.fresh(now) {
+		return entry.Body, nil
+	}
+	if ok {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := c.doResponse(c.httpClient(), req)
+	if err != nil {
+		return resp.Body, err
+	}
+
+	ttl := c.cacheTTL(req.URL.Path)
+	if ok && resp.StatusCode == http.StatusNotModified {
+		refreshed := *entry
+		refreshed.Expires = now.Add(ttl)
+		c.Cache.Set(key, &refreshed)
+		return entry.Body, nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+	noStore := strings.Contains(resp.Header.Get("Cache-Control"), "no-store")
+	if resp.StatusCode == http.StatusOK && !noStore && (ttl > 0 || etag != "" || lastModified != "") {
+		c.Cache.Set(key, &CacheEntry{
+			Body:         resp.Body,
+			ETag:         etag,
+			LastModified: lastModified,
+			Expires:      now.Add(ttl),
+		})
+	}
+	return resp.Body, nil
+}
+
+// MemoryCache is a Cache which keeps up to a fixed number of responses in
+// memory, evicting the least recently used.
+type MemoryCache struct {
+	size int
+
+	mu      sync.Mutex
+	order   *list.List // of *memoryCacheItem, most recently used first
+	entries map[string]*list.Element
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewMemoryCache returns a MemoryCache holding up to size responses.
+func NewMemoryCache(size int) *MemoryCache {
+	if size < 1 {
+		size = 1
+	}
+	return &MemoryCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the response stored for key.
+func (m *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	m.order.MoveToFront(elem)
+	return elem.Value.(*memoryCacheItem).entry, true
+}
+
+// Set stores entry for key, evicting the least recently used response if the
+// cache is full.
+func (m *MemoryCache) Set(key string, entry *CacheEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.entries[key]; ok {
+		elem.Value.(*memoryCacheItem).entry = entry
+		m.order.MoveToFront(elem)
+		return
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryCacheItem{key: key, entry: entry})
+	for m.order.Len() > m.size {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryCacheItem).key)
+	}
+}
+
+// Len returns the number of responses in the cache.
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// DiskCache is a Cache which stores each response as a file in a directory,
+// so that it survives restarts. Files are named after a hash of their key so
+// that API keys in URLs do not end up in file names.
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache returns a DiskCache storing responses in dir, which is created
+// if needed.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// path returns the file holding the response for key.
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get returns the response stored for key. Unreadable files are treated as
+// missing.
+func (d *DiskCache) Get(key string) (*CacheEntry, bool) {
+	content, err := ioutil.ReadFile(d.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry CacheEntry
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// Set stores entry for key. Write errors are ignored, as the response can
+// always be requested again.
+func (d *DiskCache) Set(key string, entry *CacheEntry) {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	// Write to a temporary file first so that concurrent readers never see
+	// half of a response.
+	tmp, err := ioutil.TempFile(d.dir, ".tmp-")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), d.path(key)); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
@@ -0,0 +1,200 @@
+package steam
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheEviction(t *testing.T) {
+	m := NewMemoryCache(2)
+	m.Set("a", &CacheEntry{Body: []byte("a")})
+	m.Set("b", &CacheEntry{Body: []byte("b")})
+	m.Get("a") // b is now the least recently used
+	m.Set("c", &CacheEntry{Body: []byte("c")})
+
+	if _, ok := m.Get("b"); ok {
+		t.Error("the least recently used response was kept")
+	}
+	for _, key := range []string{"a", "c"} {
+		if e, ok := m.Get(key); !ok || string(e.Body) != key {
+			t.Errorf("Get(%q) = %v, %v", key, e, ok)
+		}
+	}
+	if n := m.Len(); n != 2 {
+		t.Errorf("Len() = %d, want 2", n)
+	}
+
+	m.Set("a", &CacheEntry{Body: []byte("a2")})
+	if e, _ := m.Get("a"); string(e.Body) != "a2" || m.Len() != 2 {
+		t.Errorf("after replacing a: Get = %q, Len = %d", e.Body, m.Len())
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	d, err := NewDiskCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &CacheEntry{Body: []byte(`{"ok":true}`), ETag: `"v1"`, Expires: time.Unix(1700000000, 0).UTC()}
+	d.Set("https://api.steampowered.com/?key=secret", want)
+
+	// A new DiskCache on the same directory sees the response.
+	d, _ = NewDiskCache(dir)
+	got, ok := d.Get("https://api.steampowered.com/?key=secret")
+	if !ok || string(got.Body) != string(want.Body) || got.ETag != want.ETag || !got.Expires.Equal(want.Expires) {
+		t.Errorf("Get = %+v, %v, want %+v", got, ok, want)
+	}
+	if _, ok := d.Get("https://api.steampowered.com/other"); ok {
+		t.Error("Get of a missing key succeeded")
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Fatalf("%d files in the cache directory, want 1", len(files))
+	}
+	ioutil.WriteFile(filepath.Join(dir, files[0].Name()), []byte("{"), 0600)
+	if _, ok := d.Get("https://api.steampowered.com/?key=secret"); ok {
+		t.Error("Get of a corrupt file succeeded")
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	c := Client{
+		CacheTTLs: map[string]time.Duration{
+			"ISteamApps":            time.Hour,
+			"ISteamApps/GetAppList": time.Minute,
+			"groups":                10 * time.Minute,
+		},
+		DefaultCacheTTL: 5 * time.Second,
+	}
+	for _, test := range []struct {
+		path string
+		ttl  time.Duration
+	}{
+		{"/ISteamApps/GetAppList/v2/", time.Minute},
+		{"/ISteamApps/GetAppBetas/v1", time.Hour},
+		{"/ISteamApps", time.Hour},
+		{"/ISteamAppsX/GetAppList/v2/", 5 * time.Second}, // prefixes are whole segments
+		{"/groups/bots/memberslistxml", 10 * time.Minute},
+		{"/ISteamUser/GetPlayerSummaries/v2/", 5 * time.Second},
+	} {
+		if ttl := c.cacheTTL(test.path); ttl != test.ttl {
+			t.Errorf("cacheTTL(%q) = %v, want %v", test.path, ttl, test.ttl)
+		}
+	}
+
+	c.CacheTTLs = nil
+	if ttl := c.cacheTTL("/ISteamApps/GetAppList/v2/"); ttl != DefaultCacheTTLs["ISteamApps/GetAppList"] {
+		t.Errorf("cacheTTL without CacheTTLs = %v, want the default", ttl)
+	}
+}
+
+// cacheServer serves its request count as the body, with the ETag "v1" which
+// it answers with 304 Not Modified. It returns the number of requests
+// received and of those answered with 304.
+func cacheServer(t *testing.T, etag bool) (*httptest.Server, *int32, *int32) {
+	var requests, notModified int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		if etag {
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				atomic.AddInt32(&notModified, 1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+		}
+		w.Write([]byte(`{"request":` + strconv.Itoa(int(n)) + `}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests, &notModified
+}
+
+// newCacheClient returns a Client of srv with an empty MemoryCache.
+func newCacheClient(srv *httptest.Server, ttl time.Duration) (*Client, *MemoryCache) {
+	cache := NewMemoryCache(10)
+	return &Client{
+		ApiBaseURL:      srv.URL,
+		Limiter:         NewLimiter(RateLimit{}),
+		Retry:           RetryPolicy{MaxRetries: -1},
+		Cache:           cache,
+		CacheTTLs:       map[string]time.Duration{},
+		DefaultCacheTTL: ttl,
+	}, cache
+}
+
+func TestCacheExpiry(t *testing.T) {
+	srv, requests, _ := cacheServer(t, false)
+	c, cache := newCacheClient(srv, time.Hour)
+	rawurl := c.apiURL("ISteamApps/GetAppList/v2/", nil)
+
+	for i := 0; i < 3; i++ {
+		body, err := c.get(context.Background(), rawurl)
+		if err != nil || string(body) != `{"request":1}` {
+			t.Fatalf("get %d = %s, %v, want the first response", i, body, err)
+		}
+	}
+	if n := atomic.LoadInt32(requests); n != 1 {
+		t.Errorf("%d requests sent for fresh responses, want 1", n)
+	}
+
+	entry, _ := cache.Get(rawurl)
+	entry.Expires = time.Now().Add(-time.Second)
+	if body, err := c.get(context.Background(), rawurl); err != nil || string(body) != `{"request":2}` {
+		t.Errorf("get after the TTL = %s, %v, want a new response", body, err)
+	}
+
+	// Without a TTL or validator, responses are not cached.
+	srv, requests, _ = cacheServer(t, false)
+	c, cache = newCacheClient(srv, 0)
+	c.get(context.Background(), c.apiURL("ISteamApps/GetAppList/v2/", nil))
+	c.get(context.Background(), c.apiURL("ISteamApps/GetAppList/v2/", nil))
+	if n := atomic.LoadInt32(requests); n != 2 || cache.Len() != 0 {
+		t.Errorf("%d requests sent and %d responses cached without a TTL, want 2 and 0", n, cache.Len())
+	}
+}
+
+func TestCacheRevalidation(t *testing.T) {
+	srv, requests, notModified := cacheServer(t, true)
+	// Without a TTL, responses with an ETag are revalidated every time.
+	c, _ := newCacheClient(srv, 0)
+	rawurl := c.apiURL("ISteamApps/GetAppList/v2/", nil)
+
+	for i := 0; i < 3; i++ {
+		body, err := c.get(context.Background(), rawurl)
+		if err != nil || string(body) != `{"request":1}` {
+			t.Fatalf("get %d = %s, %v, want the first response", i, body, err)
+		}
+	}
+	if n, n304 := atomic.LoadInt32(requests), atomic.LoadInt32(notModified); n != 3 || n304 != 2 {
+		t.Errorf("%d requests sent and %d answered with 304, want 3 and 2", n, n304)
+	}
+}
+
+func TestCacheSkipsPostsAndSessions(t *testing.T) {
+	srv, requests, _ := cacheServer(t, true)
+	c, cache := newCacheClient(srv, time.Hour)
+	acc := &Account{Client: c, HttpClient: http.DefaultClient}
+
+	for i := 0; i < 2; i++ {
+		// QueryTime posts; only its requests matter, not the response.
+		c.QueryTime()
+		if _, err := acc.get(context.Background(), c.apiURL("ISteamApps/GetAppList/v2/", nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(requests); n != 4 {
+		t.Errorf("%d requests sent, want 4", n)
+	}
+	if n := cache.Len(); n != 0 {
+		t.Errorf("%d responses cached, want 0", n)
+	}
+}
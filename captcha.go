@@ -1,29 +1,71 @@
 package steam
 
 import (
-	"io/ioutil"
+	"context"
+	"errors"
+	"net/url"
 	"regexp"
 )
 
+// captchaGID is the captcha_gid of a dologin response, which Steam sends as
+// the number -1 when there is no captcha and as a string otherwise.
+type captchaGID string
+
+func (gid *captchaGID) UnmarshalJSON(data []byte) error {
+	text, ok, err := jsonText(data)
+	if err != nil || !ok {
+		return err
+	}
+	*gid = captchaGID(text)
+	return nil
+}
+
 // checkCaptcha returns the current captcha for an Account.
 //
 // If there is no captcha required then a -1 string will be returned.
-func (acc *Account) checkCaptcha() (string, error) {
-	resp, err := acc.HttpClient.Get("https://steamcommunity.com/login/home")
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	content, err := ioutil.ReadAll(resp.Body)
+func (acc *Account) checkCaptcha(ctx context.Context) (string, error) {
+	content, err := acc.get(ctx, acc.client().communityURL("login/home", nil))
 	if err != nil {
 		return "", err
 	}
 
 	captchaSlice := regexp.MustCompile(`\bgidCaptcha:\B\s"(\w+)"`).FindSubmatch(content)
 	if len(captchaSlice) < 2 {
-		return "", err
+		return "-1", nil
 	}
 
 	return string(captchaSlice[1]), nil
 }
+
+// captchaImage returns the image of the captcha gid.
+func (acc *Account) captchaImage(ctx context.Context, gid string) ([]byte, error) {
+	return acc.get(ctx, acc.client().communityURL("login/rendercaptcha/", url.Values{
+		"gid": {gid},
+	}))
+}
+
+// solveCaptcha fetches the image of the captcha gid and hands it to the
+// CaptchaText callback of the Account's login options. If Steam did not say
+// which captcha to solve, the one shown on the login page is used.
+func (acc *Account) solveCaptcha(ctx context.Context, gid string) (string, string, error) {
+	if gid == "" || gid == "-1" {
+		var err error
+		if gid, err = acc.checkCaptcha(ctx); err != nil {
+			return "", "", err
+		}
+		if gid == "-1" {
+			return "", "", errors.New("failed to login: unable to retrieve captcha")
+		}
+	}
+
+	image, err := acc.captchaImage(ctx, gid)
+	if err != nil {
+		return "", "", err
+	}
+
+	text, err := acc.loginOptions.CaptchaText(ctx, image)
+	if err != nil {
+		return "", "", err
+	}
+	return gid, text, nil
+}
@@ -0,0 +1,493 @@
+package steam
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ChatEventType is the kind of a ChatEvent.
+type ChatEventType int
+
+const (
+	ChatUnknown          ChatEventType = iota // a message type not known to this package
+	ChatMessage                               // a message from a friend (saytext)
+	ChatMessageEcho                           // a message sent by the Account from another client (my_saytext)
+	ChatEmote                                 // an emote from a friend (emote)
+	ChatEmoteEcho                             // an emote sent by the Account from another client (my_emote)
+	ChatTyping                                // a friend is typing (typing)
+	ChatPersonaState                          // a friend changed their name or state (personastate)
+	ChatRelationship                          // a friend request was sent, accepted or removed (personarelationship)
+	ChatLeftConversation                      // a friend closed the chat window (leftconversation)
+)
+
+// chatEventTypes maps the type of a poll message to its ChatEventType.
+var chatEventTypes = map[string]ChatEventType{
+	"saytext":             ChatMessage,
+	"my_saytext":          ChatMessageEcho,
+	"emote":               ChatEmote,
+	"my_emote":            ChatEmoteEcho,
+	"typing":              ChatTyping,
+	"personastate":        ChatPersonaState,
+	"personarelationship": ChatRelationship,
+	"leftconversation":    ChatLeftConversation,
+}
+
+// String returns the name of t.
+func (t ChatEventType) String() string {
+	for name, eventType := range chatEventTypes {
+		if eventType == t {
+			return name
+		}
+	}
+	return "ChatEventType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// ChatEvent is an event received by the web chat of an Account.
+type ChatEvent struct {
+	Type    ChatEventType
+	RawType string // the type of the poll message, eg. "saytext"
+
+	// From is the friend the event is about. For echoes it is the friend the
+	// message was sent to.
+	From SteamID64
+
+	Text string    // the text of messages and emotes
+	Time time.Time // when Steam received the event
+
+	// PersonaName, PersonaState and StatusFlags describe the friend in
+	// ChatPersonaState events. For ChatRelationship events PersonaState holds
+	// the new relationship.
+	PersonaName  string
+	PersonaState int
+	StatusFlags  int
+}
+
+// ChatState is the state of the connection of a Chat to the web chat.
+type ChatState int
+
+const (
+	ChatOffline    ChatState = iota // not running
+	ChatConnecting                  // logging on to the web chat
+	ChatOnline                      // receiving events
+	ChatDegraded                    // requests are failing and being retried
+)
+
+// String returns the name of s.
+func (s ChatState) String() string {
+	switch s {
+	case ChatOffline:
+		return "offline"
+	case ChatConnecting:
+		return "connecting"
+	case ChatOnline:
+		return "online"
+	case ChatDegraded:
+		return "degraded"
+	}
+	return "ChatState(" + strconv.Itoa(int(s)) + ")"
+}
+
+// ChatHandler handles the events received by a Chat.
+type ChatHandler interface {
+	HandleChat(e ChatEvent)
+}
+
+// ChatHandlerFunc is a function used as a ChatHandler.
+type ChatHandlerFunc func(e ChatEvent)
+
+// HandleChat calls f(e).
+func (f ChatHandlerFunc) HandleChat(e ChatEvent) {
+	f(e)
+}
+
+// DefaultChatRetryPolicy is the RetryPolicy of a Chat which has none. It
+// never gives up.
+var DefaultChatRetryPolicy = RetryPolicy{
+	MinBackoff: time.Second,
+	MaxBackoff: time.Minute,
+}
+
+// errUmqidExpired is returned by a poll of the web chat when its umqid is no
+// longer valid, although the access token may still be.
+var errUmqidExpired = errors.New("steam: web chat session expired")
+
+// Chat receives the web chat events of an Account and passes them to its
+// Handler. Unlike ListenAndServe, its Run method reconnects after errors.
+//
+// A Chat remembers its position in the event queue of the web chat, so
+// reconnecting neither misses nor repeats events. It must not be Run by
+// several goroutines at once.
+type Chat struct {
+	Account *Account
+	Handler ChatHandler
+
+	// Retry controls the delay between reconnections after consecutive
+	// failures. Run gives up after MaxRetries consecutive failures, or never
+	// if it is zero. If the zero value, DefaultChatRetryPolicy is used.
+	Retry RetryPolicy
+
+	// OnError, if set, is called with the errors Run recovers from.
+	OnError func(err error)
+
+	// OnState, if set, is called when the state of the connection changes.
+	// err is the error which caused the change, if any.
+	OnState func(state ChatState, err error)
+
+	state  ChatState
+	cursor chatCursor
+}
+
+// chatCursor is the position of a Chat in the event queue of the web chat.
+type chatCursor struct {
+	umqid       string // the queue, resumed when logging on again
+	accessToken string // the token umqid was logged on with
+	generation  uint64 // the session generation of accessToken
+	loggedOn    bool   // whether umqid can be polled
+	pollid      int64
+	message     int64 // the last event received
+	sectimeout  int64
+}
+
+// chatLogon is the result of a logon to the web chat.
+type chatLogon struct {
+	umqid       string
+	accessToken string
+	message     int64 // the last event queued
+}
+
+// Chat returns a Chat passing the chat events of acc to handler.
+func (acc *Account) Chat(handler ChatHandler) *Chat {
+	return &Chat{Account: acc, Handler: handler}
+}
+
+// retryPolicy returns the RetryPolicy used by c.
+func (c *Chat) retryPolicy() RetryPolicy {
+	if c.Retry == (RetryPolicy{}) {
+		return DefaultChatRetryPolicy
+	}
+	return c.Retry
+}
+
+// setState records the state of c and calls OnState if it changed.
+func (c *Chat) setState(state ChatState, err error) {
+	if state == c.state {
+		return
+	}
+	c.state = state
+	if c.OnState != nil {
+		c.OnState(state, err)
+	}
+}
+
+// Run receives chat events until ctx is done, and then returns ctx.Err().
+// When the web chat session expires Run logs on again; an expired login
+// session is logged in again if the Account has a Password. Other errors are
+// retried according to c.Retry. An error is only returned if logging in again
+// is refused or Retry gives up.
+func (c *Chat) Run(ctx context.Context) (err error) {
+	acc := c.Account
+	policy := c.retryPolicy()
+	failures := 0
+
+	c.setState(ChatConnecting, nil)
+	defer func() {
+		c.setState(ChatOffline, err)
+	}()
+
+	for {
+		gen := acc.sessionGeneration()
+		online, err := c.listen(ctx, gen)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if online {
+			failures = 0
+		}
+		if c.OnError != nil {
+			c.OnError(err)
+		}
+
+		switch {
+		case errors.Is(err, errUmqidExpired):
+			// The access token usually outlives the umqid, so logging on
+			// again is enough.
+			c.cursor.loggedOn = false
+			c.setState(ChatConnecting, err)
+			if online {
+				continue
+			}
+
+		case errors.Is(err, ErrSessionExpired):
+			if acc.Password == "" {
+				return err
+			}
+			c.cursor.loggedOn = false
+			c.setState(ChatConnecting, err)
+			err = acc.reloginAfter(ctx, gen, err)
+			if err == nil {
+				continue
+			}
+			if isLoginError(err) {
+				return err
+			}
+			c.setState(ChatDegraded, err)
+
+		default:
+			c.setState(ChatDegraded, err)
+		}
+
+		if policy.MaxRetries > 0 && failures >= policy.MaxRetries {
+			return err
+		}
+		delay, _ := policy.backoff(failures, 0)
+		failures++
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// isLoginError reports whether err is a login failure which trying again
+// would not fix.
+func isLoginError(err error) bool {
+	for _, target := range []error{
+		ErrInvalidCredentials,
+		ErrTwoFactorRequired, ErrTwoFactorRejected,
+		ErrEmailAuthRequired, ErrEmailAuthRejected,
+		ErrCaptchaRequired, ErrCaptchaRejected,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// listen logs on to the web chat unless c is logged on in the session
+// generation gen already, and passes the events received to the Handler until
+// an error occurs. online reports whether a request succeeded before the
+// error.
+func (c *Chat) listen(ctx context.Context, gen uint64) (online bool, err error) {
+	if c.cursor.generation != gen {
+		// The queue belonged to the previous session.
+		c.cursor = chatCursor{generation: gen}
+	}
+
+	if !c.cursor.loggedOn {
+		if err := c.logon(ctx); err != nil {
+			return false, err
+		}
+		online = true
+		c.setState(ChatOnline, nil)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return online, err
+		}
+		events, err := c.Account.poll(ctx, &c.cursor)
+		if err != nil {
+			return online, err
+		}
+		online = true
+		c.setState(ChatOnline, nil)
+		for _, e := range events {
+			c.Handler.HandleChat(e)
+		}
+	}
+}
+
+// logon logs c on to the web chat, resuming its queue, or else the one of the
+// Account, if Steam still has it. Otherwise the cursor of c is moved to the
+// new queue, which the Account uses from then on.
+func (c *Chat) logon(ctx context.Context) error {
+	acc := c.Account
+	acc.chatMu.Lock()
+	defer acc.chatMu.Unlock()
+
+	resume := c.cursor.umqid
+	if resume == "" {
+		acc.mu.Lock()
+		resume = acc.Umqid
+		acc.mu.Unlock()
+	}
+	logon, err := acc.chatLogon(ctx, resume)
+	if err != nil {
+		return err
+	}
+
+	if logon.umqid != c.cursor.umqid {
+		c.cursor.umqid = logon.umqid
+		c.cursor.pollid = 1
+		c.cursor.message = logon.message
+	}
+	c.cursor.accessToken = logon.accessToken
+	c.cursor.loggedOn = true
+	if c.cursor.sectimeout == 0 {
+		c.cursor.sectimeout = 20
+	}
+	return nil
+}
+
+// chatLogon logs acc on to the web chat, resuming the queue resume if it is
+// not empty and Steam still has it, and stores the umqid and access token of
+// the chat session. The access token is obtained first if acc has none.
+// acc.chatMu must be held.
+func (acc *Account) chatLogon(ctx context.Context, resume string) (*chatLogon, error) {
+	acc.mu.Lock()
+	accessToken := acc.AccessToken
+	acc.mu.Unlock()
+	if accessToken == "" {
+		var err error
+		if accessToken, err = acc.getAccessToken(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	query := url.Values{
+		"jsonp":        {"1"},
+		"ui_mode":      {"web"},
+		"access_token": {accessToken},
+		"_":            {strconv.FormatInt(makeTimestamp(), 10)},
+	}
+	if resume != "" {
+		query.Set("umqid", resume)
+	}
+	content, err := acc.get(ctx, acc.client().apiURL("ISteamWebUserPresenceOAuth/Logon/v0001/", query))
+	if err != nil {
+		return nil, err
+	}
+	content = unwrapJSONP(content)
+
+	var logonResponse struct {
+		Steamid       string
+		Error         string
+		Umqid         string
+		Timestamp     int64
+		Utc_timestamp int64
+		Message       int
+		Push          int
+	}
+	if err = unmarshalJSON(content, &logonResponse); err != nil {
+		return nil, err
+	}
+
+	if logonResponse.Error != "OK" {
+		return nil, presenceError(logonResponse.Error)
+	}
+	if logonResponse.Umqid == "" {
+		return nil, errors.New("unable to retrieve umqid")
+	}
+
+	acc.mu.Lock()
+	acc.Umqid, acc.AccessToken = logonResponse.Umqid, accessToken
+	acc.mu.Unlock()
+
+	steamid, err := strconv.ParseInt(logonResponse.Steamid, 10, 64)
+	if err == nil {
+		acc.setSteamID(SteamID64(steamid))
+	}
+
+	return &chatLogon{
+		umqid:       logonResponse.Umqid,
+		accessToken: accessToken,
+		message:     int64(logonResponse.Message),
+	}, nil
+}
+
+// poll waits for the events after cursor and moves cursor past them.
+func (acc *Account) poll(ctx context.Context, cursor *chatCursor) ([]ChatEvent, error) {
+	content, err := acc.get(ctx, acc.client().apiURL("ISteamWebUserPresenceOAuth/Poll/v0001/", url.Values{
+		"jsonp":          {"1"},
+		"umqid":          {cursor.umqid},
+		"message":        {strconv.FormatInt(cursor.message, 10)},
+		"pollid":         {strconv.FormatInt(cursor.pollid, 10)},
+		"sectimeout":     {strconv.FormatInt(cursor.sectimeout, 10)},
+		"secidletime":    {"0"},
+		"use_accountids": {"1"},
+		"access_token":   {cursor.accessToken},
+		"_":              {strconv.FormatInt(makeTimestamp(), 10)},
+	}))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	content = unwrapJSONP(content)
+
+	var pollResponse struct {
+		Pollid     int64
+		Sectimeout int64
+		Error      string
+		Messages   []struct {
+			Type           string
+			Timestamp      int64
+			Utc_timestamp  int64
+			Accountid_from int64
+			Text           string
+			Status_flags   int
+			Persona_state  int
+			Persona_name   string
+		}
+		Messagelast   int
+		Timestamp     int64
+		Utc_timestamp int64
+		Messagebase   int
+	}
+	if err = unmarshalJSON(content, &pollResponse); err != nil {
+		return nil, err
+	}
+
+	switch pollResponse.Error {
+	case "OK":
+	case "Timeout":
+		if pollResponse.Sectimeout > 20 {
+			cursor.sectimeout = pollResponse.Sectimeout
+		}
+
+		if pollResponse.Sectimeout < 120 {
+			if cursor.sectimeout+5 < 120 {
+				cursor.sectimeout = cursor.sectimeout + 5
+			} else {
+				cursor.sectimeout = 120
+			}
+		}
+		cursor.pollid = pollResponse.Pollid + 1
+		return nil, nil
+	default:
+		if errors.Is(presenceError(pollResponse.Error), ErrSessionExpired) {
+			return nil, errUmqidExpired
+		}
+		return nil, presenceError(pollResponse.Error)
+	}
+
+	events := make([]ChatEvent, 0, len(pollResponse.Messages))
+	for _, message := range pollResponse.Messages {
+		eventType, ok := chatEventTypes[message.Type]
+		if !ok {
+			eventType = ChatUnknown
+		}
+		eventTime := time.Unix(message.Utc_timestamp, 0)
+		if message.Utc_timestamp == 0 {
+			eventTime = time.Now()
+		}
+		events = append(events, ChatEvent{
+			Type:         eventType,
+			RawType:      message.Type,
+			From:         SteamID32ToSteamID64(SteamID32(message.Accountid_from)),
+			Text:         message.Text,
+			Time:         eventTime,
+			PersonaName:  message.Persona_name,
+			PersonaState: message.Persona_state,
+			StatusFlags:  message.Status_flags,
+		})
+	}
+
+	cursor.pollid = pollResponse.Pollid + 1
+	cursor.message = int64(pollResponse.Messagelast)
+	return events, nil
+}
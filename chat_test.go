@@ -0,0 +1,98 @@
+package steam_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Acidic9/steam"
+	"github.com/Acidic9/steam/steamtest"
+)
+
+func TestChatEventTypes(t *testing.T) {
+	srv := newServer(t)
+	acc, _ := login(t, srv)
+	events, _ := startChat(t, acc)
+
+	from := friendIDs[0]
+	tests := []struct {
+		sent steamtest.ChatEvent
+		want steam.ChatEvent
+	}{
+		{steamtest.ChatEvent{Type: "my_saytext", Text: "sent elsewhere"}, steam.ChatEvent{Type: steam.ChatMessageEcho, Text: "sent elsewhere"}},
+		{steamtest.ChatEvent{Type: "emote", Text: "waves"}, steam.ChatEvent{Type: steam.ChatEmote, Text: "waves"}},
+		{steamtest.ChatEvent{Type: "my_emote", Text: "nods"}, steam.ChatEvent{Type: steam.ChatEmoteEcho, Text: "nods"}},
+		{steamtest.ChatEvent{Type: "typing"}, steam.ChatEvent{Type: steam.ChatTyping}},
+		{steamtest.ChatEvent{Type: "personastate", PersonaName: "renamed", PersonaState: 3, StatusFlags: 16}, steam.ChatEvent{Type: steam.ChatPersonaState, PersonaName: "renamed", PersonaState: 3, StatusFlags: 16}},
+		{steamtest.ChatEvent{Type: "personarelationship", PersonaState: 2}, steam.ChatEvent{Type: steam.ChatRelationship, PersonaState: 2}},
+		{steamtest.ChatEvent{Type: "leftconversation"}, steam.ChatEvent{Type: steam.ChatLeftConversation}},
+		{steamtest.ChatEvent{Type: "something_new"}, steam.ChatEvent{Type: steam.ChatUnknown}},
+	}
+	for _, test := range tests {
+		test.sent.From = from
+		srv.DeliverEvent(botID, test.sent)
+	}
+
+	for _, test := range tests {
+		select {
+		case e := <-events:
+			want := test.want
+			want.From, want.RawType = from, test.sent.Type
+			want.Time = e.Time
+			if e != want {
+				t.Errorf("%s: received %+v, want %+v", test.sent.Type, e, want)
+			}
+			if e.Time.IsZero() || time.Since(e.Time) > time.Minute {
+				t.Errorf("%s: Time = %v", test.sent.Type, e.Time)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("the %s event was not received", test.sent.Type)
+		}
+	}
+}
+
+func TestChatResume(t *testing.T) {
+	const (
+		logonPath = "/ISteamWebUserPresenceOAuth/Logon/v0001/"
+		pollPath  = "/ISteamWebUserPresenceOAuth/Poll/v0001/"
+	)
+	srv := newServer(t)
+	acc, _ := login(t, srv)
+	events, states := startChat(t, acc)
+
+	from := friendIDs[0]
+	srv.DeliverMessage(from, botID, "one")
+	waitMessage(t, events, from, "one")
+
+	var umqid string
+	before := len(srv.Requests())
+	for _, r := range srv.Requests() {
+		if r.Path == pollPath {
+			umqid = r.Form.Get("umqid")
+		}
+	}
+
+	// "two" arrives while the chat session is expired: it is only received
+	// if the Chat resumes its umqid and cursor rather than starting over.
+	srv.ExpireChatSessions()
+	srv.DeliverMessage(from, botID, "two")
+	waitState(t, states, steam.ChatOnline)
+	srv.DeliverMessage(from, botID, "three")
+
+	waitMessage(t, events, from, "two")
+	waitMessage(t, events, from, "three")
+	select {
+	case e := <-events:
+		t.Errorf("received %+v after the last message", e)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	var logons []string
+	for _, r := range srv.Requests()[before:] {
+		if r.Path == logonPath {
+			logons = append(logons, r.Form.Get("umqid"))
+		}
+	}
+	if len(logons) != 1 || logons[0] != umqid {
+		t.Errorf("logons after the expiry sent the umqids %q, want [%q]", logons, umqid)
+	}
+}
@@ -0,0 +1,356 @@
+package steam
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// The base URLs used by a Client which doesn't set its own.
+const (
+	DefaultApiBaseURL       = "https://api.steampowered.com"
+	DefaultCommunityBaseURL = "https://steamcommunity.com"
+	DefaultPartnerBaseURL   = "https://partner.steam-api.com"
+)
+
+// Client holds the settings used to talk to the Steam Web API and the Steam
+// community website. The zero value is ready to use and talks to the public
+// Steam hosts using http.DefaultClient, with the default rate limits and
+// retries.
+type Client struct {
+	// HttpClient is used to make requests. If nil, http.DefaultClient is used.
+	HttpClient *http.Client
+
+	// ApiBaseURL and CommunityBaseURL are the base URLs of the Web API and
+	// the community website, without a trailing slash. If empty,
+	// DefaultApiBaseURL and DefaultCommunityBaseURL are used.
+	ApiBaseURL       string
+	CommunityBaseURL string
+
+	// PartnerBaseURL is the base URL of the Web API methods which require a
+	// publisher key. If empty, DefaultPartnerBaseURL is used.
+	PartnerBaseURL string
+
+	// ApiKey is sent with the Web API requests which require one.
+	ApiKey string
+
+	// UserAgent, if set, is sent as the User-Agent header of every request.
+	UserAgent string
+
+	// Language, if set, is sent as the "l" parameter of Web API requests to
+	// localise their responses (eg. "english" or "german").
+	Language string
+
+	// Limiter paces the requests sent to each host. If nil, a Limiter shared
+	// by every Client without one is used, which applies DefaultApiRateLimit
+	// and DefaultCommunityRateLimit. Use NewLimiter(RateLimit{}) for no limit.
+	Limiter *Limiter
+
+	// Retry controls how failed requests are retried. If the zero value,
+	// DefaultRetryPolicy is used. A negative MaxRetries disables retries.
+	Retry RetryPolicy
+
+	// Cache, if set, stores the responses of the Client's read-only
+	// requests. Requests made with the session of an Account are never cached.
+	Cache Cache
+
+	// CacheTTLs maps a Web API method (eg. "ISteamApps/GetAppList") or a
+	// community path (eg. "groups") to how long its responses stay fresh.
+	// The longest matching prefix is used. If nil, DefaultCacheTTLs is used.
+	CacheTTLs map[string]time.Duration
+
+	// DefaultCacheTTL is how long the responses of other requests stay fresh.
+	// With the zero value they are only cached if they can be revalidated
+	// with an ETag or Last-Modified header.
+	DefaultCacheTTL time.Duration
+}
+
+// DefaultClient is the Client used by the package level functions.
+var DefaultClient = NewClient("")
+
+// sharedLimiter is the Limiter of the Clients which have none.
+var sharedLimiter = newDefaultLimiter()
+
+// NewClient returns a Client which uses apiKey for Web API requests.
+// Its requests are paced by DefaultApiRateLimit and DefaultCommunityRateLimit,
+// sharing the budget of each host with DefaultClient and the other Clients
+// without a Limiter, and retried according to DefaultRetryPolicy.
+func NewClient(apiKey string) *Client {
+	return &Client{
+		ApiKey:  apiKey,
+		Limiter: sharedLimiter,
+		Retry:   DefaultRetryPolicy,
+	}
+}
+
+// newDefaultLimiter returns a Limiter applying DefaultApiRateLimit and
+// DefaultCommunityRateLimit.
+func newDefaultLimiter() *Limiter {
+	limiter := NewLimiter(DefaultApiRateLimit)
+	limiter.SetLimit(hostOf(DefaultApiBaseURL), DefaultApiRateLimit)
+	limiter.SetLimit(hostOf(DefaultCommunityBaseURL), DefaultCommunityRateLimit)
+	return limiter
+}
+
+// limiter returns the Limiter used by c.
+func (c *Client) limiter() *Limiter {
+	if c.Limiter != nil {
+		return c.Limiter
+	}
+	return sharedLimiter
+}
+
+// retryPolicy returns the RetryPolicy used by c.
+func (c *Client) retryPolicy() RetryPolicy {
+	if c.Retry == (RetryPolicy{}) {
+		return DefaultRetryPolicy
+	}
+	return c.Retry
+}
+
+// hostOf returns the host of rawurl.
+func hostOf(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// withApiKey returns a copy of c which uses apiKey for Web API requests.
+func (c *Client) withApiKey(apiKey string) *Client {
+	client := *c
+	client.ApiKey = apiKey
+	return &client
+}
+
+// httpClient returns the http.Client used by c.
+func (c *Client) httpClient() *http.Client {
+	if c.HttpClient != nil {
+		return c.HttpClient
+	}
+	return http.DefaultClient
+}
+
+// apiURL returns the URL of a Web API method such as "ISteamApps/GetAppList/v1".
+func (c *Client) apiURL(method string, query url.Values) string {
+	base := c.ApiBaseURL
+	if base == "" {
+		base = DefaultApiBaseURL
+	}
+
+	if c.Language != "" {
+		if query == nil {
+			query = url.Values{}
+		}
+		query.Set("l", c.Language)
+	}
+
+	return joinURL(base, method, query)
+}
+
+// partnerURL returns the URL of a Web API method called with a publisher key.
+func (c *Client) partnerURL(method string, query url.Values) string {
+	base := c.PartnerBaseURL
+	if base == "" {
+		base = DefaultPartnerBaseURL
+	}
+	return joinURL(base, method, query)
+}
+
+// communityURL returns the URL of a path on the community website.
+func (c *Client) communityURL(path string, query url.Values) string {
+	base := c.CommunityBaseURL
+	if base == "" {
+		base = DefaultCommunityBaseURL
+	}
+	return joinURL(base, path, query)
+}
+
+// joinURL joins a base URL, a path and an optional query.
+func joinURL(base, path string, query url.Values) string {
+	u := strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+// newRequest returns a request for rawurl bound to ctx with the Client's
+// headers set. If form is not nil it is sent as an
+// application/x-www-form-urlencoded body.
+func (c *Client) newRequest(ctx context.Context, method, rawurl string, form url.Values) (*http.Request, error) {
+	var req *http.Request
+	var err error
+	if form != nil {
+		req, err = http.NewRequestWithContext(ctx, method, rawurl, strings.NewReader(form.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	} else {
+		req, err = http.NewRequestWithContext(ctx, method, rawurl, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	return req, nil
+}
+
+// response is the part of an HTTP response kept by the Client.
+type response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	URL        *url.URL // the URL of the final request, after redirects
+}
+
+// do sends req using hc and returns the response body. The request waits for
+// the Client's Limiter and is retried according to its RetryPolicy.
+//
+// Error statuses are returned as an *HTTPError, unless the body is JSON (some
+// Web API methods describe the error in the body) in which case it is left
+// for the caller to decode.
+func (c *Client) do(hc *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := c.doResponse(hc, req)
+	return resp.Body, err
+}
+
+// doResponse is like do but returns the status code and headers as well.
+func (c *Client) doResponse(hc *http.Client, req *http.Request) (response, error) {
+	ctx := req.Context()
+	limiter, policy := c.limiter(), c.retryPolicy()
+
+	for attempt := 0; ; attempt++ {
+		if err := limiter.Wait(ctx, req.URL.Host); err != nil {
+			return response{}, err
+		}
+
+		r := req
+		if attempt > 0 {
+			r = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return response{}, err
+				}
+				r.Body = body
+			}
+		}
+
+		resp, err := c.send(hc, r)
+		if err == nil || ctx.Err() != nil || attempt >= policy.MaxRetries || !retryable(req, err) {
+			return resp, err
+		}
+
+		var retryAfter time.Duration
+		if httpErr, ok := err.(*HTTPError); ok {
+			retryAfter = httpErr.RetryAfter
+		}
+		delay, ok := policy.backoff(attempt, retryAfter)
+		if !ok {
+			return resp, err
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return response{}, err
+		}
+	}
+}
+
+// send sends req once using hc.
+func (c *Client) send(hc *http.Client, req *http.Request) (response, error) {
+	resp, err := hc.Do(req)
+	if err != nil {
+		return response{}, err
+	}
+	defer resp.Body.Close()
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return response{}, err
+	}
+
+	r := response{StatusCode: resp.StatusCode, Header: resp.Header, Body: content, URL: resp.Request.URL}
+	if resp.StatusCode >= 400 && (resp.StatusCode == http.StatusTooManyRequests || !isJSON(content)) {
+		return r, &HTTPError{
+			StatusCode: resp.StatusCode,
+			Message:    htmlErrorMessage(content),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	return r, nil
+}
+
+// isJSON reports whether content looks like a JSON object or array.
+func isJSON(content []byte) bool {
+	trimmed := strings.TrimSpace(string(content))
+	return strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")
+}
+
+// get requests rawurl using the Client's http.Client and returns the response
+// body, going through the Client's Cache if it has one.
+func (c *Client) get(ctx context.Context, rawurl string) ([]byte, error) {
+	req, err := c.newRequest(ctx, "GET", rawurl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.Cache != nil {
+		return c.getCached(req)
+	}
+	return c.do(c.httpClient(), req)
+}
+
+// client returns the Client used by acc.
+func (acc *Account) client() *Client {
+	if acc.Client != nil {
+		return acc.Client
+	}
+	return DefaultClient
+}
+
+// get requests rawurl with the Account's session and returns the response body.
+func (acc *Account) get(ctx context.Context, rawurl string) ([]byte, error) {
+	req, err := acc.client().newRequest(ctx, "GET", rawurl, nil)
+	if err != nil {
+		return nil, err
+	}
+	return acc.do(req)
+}
+
+// postForm posts form to rawurl with the Account's session and returns the
+// response body.
+func (acc *Account) postForm(ctx context.Context, rawurl string, form url.Values) ([]byte, error) {
+	req, err := acc.client().newRequest(ctx, "POST", rawurl, form)
+	if err != nil {
+		return nil, err
+	}
+	return acc.do(req)
+}
+
+// do sends req with the Account's session and returns the response body.
+// ErrSessionExpired is returned when the session is no longer accepted: the
+// request was redirected to the login page, or was refused with a 401.
+func (acc *Account) do(req *http.Request) ([]byte, error) {
+	resp, err := acc.client().doResponse(acc.httpClient(), req)
+	if httpErr, ok := err.(*HTTPError); ok && httpErr.StatusCode == http.StatusUnauthorized {
+		return resp.Body, ErrSessionExpired
+	}
+	if err == nil && resp.URL != nil && loginRedirect(req.URL, resp.URL) {
+		return resp.Body, ErrSessionExpired
+	}
+	return resp.Body, err
+}
+
+// loginRedirect reports whether a request to from ended up on the login page
+// at to, which Steam does when a page requiring a session is requested
+// without one.
+func loginRedirect(from, to *url.URL) bool {
+	return strings.HasPrefix(to.Path, "/login/home") && !strings.HasPrefix(from.Path, "/login")
+}
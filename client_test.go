@@ -0,0 +1,112 @@
+package steam
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer answers 503 Service Unavailable to the first failures requests
+// and 200 OK to the others. It returns the number of requests received.
+func flakyServer(t *testing.T, failures int32) (*httptest.Server, *int32) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= failures {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestZeroClientDefaults(t *testing.T) {
+	var c Client
+	if c.limiter() != sharedLimiter {
+		t.Error("a Client without a Limiter does not use the shared Limiter")
+	}
+	if c.retryPolicy() != DefaultRetryPolicy {
+		t.Errorf("a Client without a RetryPolicy uses %+v, want DefaultRetryPolicy", c.retryPolicy())
+	}
+
+	srv, requests := flakyServer(t, 1)
+	c = Client{ApiBaseURL: srv.URL, Limiter: NewLimiter(RateLimit{})}
+	if _, err := c.get(context.Background(), c.apiURL("ISteamApps/GetAppList/v2/", nil)); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if n := atomic.LoadInt32(requests); n != 2 {
+		t.Errorf("%d requests sent, want 2", n)
+	}
+}
+
+func TestClientRetriesDisabled(t *testing.T) {
+	srv, requests := flakyServer(t, 1)
+	c := Client{ApiBaseURL: srv.URL, Limiter: NewLimiter(RateLimit{}), Retry: RetryPolicy{MaxRetries: -1}}
+	if _, err := c.get(context.Background(), c.apiURL("ISteamApps/GetAppList/v2/", nil)); err == nil {
+		t.Fatal("get succeeded, want the 503 error")
+	}
+	if n := atomic.LoadInt32(requests); n != 1 {
+		t.Errorf("%d requests sent, want 1", n)
+	}
+}
+
+func TestDefaultClientSharesLimiter(t *testing.T) {
+	if DefaultClient.limiter() != sharedLimiter {
+		t.Error("DefaultClient does not use the shared Limiter")
+	}
+	if NewClient("key").limiter() != sharedLimiter {
+		t.Error("NewClient does not use the shared Limiter")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{MaxRetries: 10, MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for attempt, max := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		for i := 0; i < 100; i++ {
+			delay, ok := p.backoff(attempt, 0)
+			if !ok || delay < max/2 || delay > max {
+				t.Fatalf("backoff(%d) = %v, %v, want between %v and %v", attempt, delay, ok, max/2, max)
+			}
+		}
+	}
+
+	if delay, ok := p.backoff(0, 300*time.Millisecond); !ok || delay != 300*time.Millisecond {
+		t.Errorf("backoff with Retry-After 300ms = %v, %v", delay, ok)
+	}
+	if _, ok := p.backoff(0, 2*time.Second); ok {
+		t.Error("backoff waits for a Retry-After longer than MaxBackoff")
+	}
+}
+
+func TestRetryAfterBeyondMaxBackoff(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	c := Client{
+		ApiBaseURL: srv.URL,
+		Limiter:    NewLimiter(RateLimit{}),
+		Retry:      RetryPolicy{MaxRetries: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Second},
+	}
+	start := time.Now()
+	_, err := c.get(context.Background(), c.apiURL("ISteamApps/GetAppList/v2/", nil))
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusTooManyRequests || httpErr.RetryAfter != time.Minute {
+		t.Errorf("get: %v, want a 429 *HTTPError asking for a minute", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("%d requests sent, want 1", n)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("get returned after %v", elapsed)
+	}
+}
@@ -1,16 +1,15 @@
 package steam
 
 import (
+	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
-	"sync"
 )
 
 // A PlayerSummaries stores all general profile information for a steam user.
@@ -48,29 +47,45 @@ type FriendsList []struct {
 }
 
 // Message sends a message to a specified SteamID64 using a logged in Account.
+// It is sent through the MessageQueue of the Account, and returns once it was
+// delivered or failed.
 func (acc *Account) Message(recipient SteamID64, message string) error {
-	if len(acc.Umqid) <= 0 {
-		umqid := acc.getUmqid()
-		if umqid == "" {
-			return errors.New("unable to retrieve umqid")
-		}
+	return acc.MessageContext(context.Background(), recipient, message)
+}
+
+// MessageContext is like Message but its requests are bound to ctx.
+func (acc *Account) MessageContext(ctx context.Context, recipient SteamID64, message string) error {
+	return (<-acc.messageQueue().Send(ctx, recipient, message)).Err
+}
 
-		acc.Umqid = umqid
+// message sends message to recipient. If Steam no longer knows the umqid of
+// acc, it logs on to the web chat again and retries once.
+func (acc *Account) message(ctx context.Context, recipient SteamID64, message string) error {
+	umqid, accessToken, err := acc.chatCredentials(ctx)
+	if err != nil {
+		return err
 	}
-	if len(acc.AccessToken) <= 0 {
-		accessToken := acc.getAccessToken()
-		if accessToken == "" {
-			return errors.New("unable to retrieve umqid")
-		}
 
-		acc.AccessToken = accessToken
+	err = acc.postMessage(ctx, umqid, accessToken, recipient, message)
+	if !errors.Is(err, ErrSessionExpired) {
+		return err
 	}
+	// The access token usually outlives the umqid. If it expired too, the
+	// logon fails with ErrSessionExpired.
+	if umqid, accessToken, err = acc.renewChat(ctx, umqid); err != nil {
+		return err
+	}
+	return acc.postMessage(ctx, umqid, accessToken, recipient, message)
+}
 
-	resp, err := acc.HttpClient.PostForm("https://api.steampowered.com/ISteamWebUserPresenceOAuth/Message/v0001/", url.Values{
+// postMessage sends message to recipient through the chat session umqid. The
+// request is not retried by the Client, as the MessageQueue retries messages.
+func (acc *Account) postMessage(ctx context.Context, umqid, accessToken string, recipient SteamID64, message string) error {
+	content, err := acc.postForm(withRetryMode(ctx, retryNever), acc.client().apiURL("ISteamWebUserPresenceOAuth/Message/v0001/", nil), url.Values{
 		"steamid_dst":  {strconv.FormatUint(uint64(recipient), 10)},
 		"text":         {message},
-		"umqid":        {acc.Umqid},
-		"access_token": {acc.AccessToken},
+		"umqid":        {umqid},
+		"access_token": {accessToken},
 		"type":         {"saytext"},
 		"jsonp":        {"1"},
 		"_":            {strconv.FormatInt(makeTimestamp(), 10)},
@@ -78,39 +93,34 @@ func (acc *Account) Message(recipient SteamID64, message string) error {
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	content, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
 
 	var messageResponse struct {
 		Error string
 	}
-	if err := json.Unmarshal(content, &messageResponse); err != nil {
-		if err.Error() == "invalid character '<' looking for beginning of value" {
-			return jsonUnmarshallErrorCheck(content)
-		}
+	if err := unmarshalJSON(unwrapJSONP(content), &messageResponse); err != nil {
 		return err
 	}
 
 	if strings.ToLower(messageResponse.Error) != "ok" {
-		return errors.New(messageResponse.Error)
+		return presenceError(messageResponse.Error)
 	}
 
 	return nil
 }
 
 // Broadcast sends a specified message to all SteamID's for Account.
+// If it could not be delivered to some of them a *BroadcastError is returned.
 func (acc *Account) Broadcast(message string) error {
-	resp, err := acc.HttpClient.Get("http://steamcommunity.com/profiles/" + strconv.FormatUint(uint64(acc.SteamID), 10) + "/friends")
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+	return acc.BroadcastContext(context.Background(), message)
+}
 
-	content, err := ioutil.ReadAll(resp.Body)
+// BroadcastContext is like Broadcast but its requests are bound to ctx.
+func (acc *Account) BroadcastContext(ctx context.Context, message string) error {
+	var content []byte
+	err := acc.withRelogin(ctx, func() (err error) {
+		content, err = acc.get(ctx, acc.client().communityURL("profiles/"+strconv.FormatUint(uint64(acc.steamID()), 10)+"/friends", nil))
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -128,47 +138,64 @@ func (acc *Account) Broadcast(message string) error {
 		}
 	}
 
-	var wg sync.WaitGroup
-
-	for _, friend := range friends {
-		wg.Add(1)
-		go func(friendID SteamID64) {
-			defer wg.Done()
-			err = acc.Message(friendID, message)
-			if err != nil {
-				fmt.Println(friendID, err)
-			}
-		}(friend)
+	broadcastErr := &BroadcastError{}
+	for _, result := range acc.messageQueue().SendAll(ctx, friends, message) {
+		if result.Err != nil {
+			broadcastErr.Failed = append(broadcastErr.Failed, result)
+		} else {
+			broadcastErr.Sent++
+		}
+	}
+	if len(broadcastErr.Failed) > 0 {
+		return broadcastErr
 	}
-
-	wg.Wait()
 	return nil
 }
 
 // ChangeProfilePic changes the account's profile picture to a default app avatar.
 // For example:
-//     acc.ChangeProfilePic(252490, 0)
+//
+//	acc.ChangeProfilePic(252490, 0)
 func (acc *Account) ChangeProfilePic(appID uint64, selectedAvatar uint64) error {
-	sessionID, err := acc.getSessionId()
+	return acc.ChangeProfilePicContext(context.Background(), appID, selectedAvatar)
+}
+
+// ChangeProfilePicContext is like ChangeProfilePic but its requests are bound to ctx.
+func (acc *Account) ChangeProfilePicContext(ctx context.Context, appID uint64, selectedAvatar uint64) error {
+	return acc.withRelogin(ctx, func() error {
+		return acc.changeProfilePic(ctx, appID, selectedAvatar)
+	})
+}
+
+// changeProfilePic selects the avatar selectedAvatar of appID.
+func (acc *Account) changeProfilePic(ctx context.Context, appID uint64, selectedAvatar uint64) error {
+	sessionID, err := acc.getSessionId(ctx)
 	if err != nil {
 		return err
 	}
 
-	resp, err := acc.HttpClient.PostForm("http://steamcommunity.com/games/"+strconv.FormatUint(appID, 10)+"/selectAvatar", url.Values{
+	_, err = acc.postForm(ctx, acc.client().communityURL("games/"+strconv.FormatUint(appID, 10)+"/selectAvatar", nil), url.Values{
 		"selectedAvatar": {strconv.FormatUint(selectedAvatar, 10)},
 		"sessionid":      {sessionID},
 	})
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	return nil
+	return err
 }
 
 // InviteToGroup invites a set of SteamID64's to a Steam group.
 func (acc *Account) InviteToGroup(groupID GroupID, recipients ...SteamID64) error {
-	sessionID, err := acc.getSessionId()
+	return acc.InviteToGroupContext(context.Background(), groupID, recipients...)
+}
+
+// InviteToGroupContext is like InviteToGroup but its requests are bound to ctx.
+func (acc *Account) InviteToGroupContext(ctx context.Context, groupID GroupID, recipients ...SteamID64) error {
+	return acc.withRelogin(ctx, func() error {
+		return acc.inviteToGroup(ctx, groupID, recipients)
+	})
+}
+
+// inviteToGroup invites recipients to the group groupID.
+func (acc *Account) inviteToGroup(ctx context.Context, groupID GroupID, recipients []SteamID64) error {
+	sessionID, err := acc.getSessionId(ctx)
 	if err != nil {
 		return err
 	}
@@ -182,7 +209,7 @@ func (acc *Account) InviteToGroup(groupID GroupID, recipients ...SteamID64) erro
 	}
 	inviteeList += `]`
 
-	resp, err := acc.HttpClient.PostForm("http://steamcommunity.com/actions/GroupInvite", url.Values{
+	content, err := acc.postForm(ctx, acc.client().communityURL("actions/GroupInvite", nil), url.Values{
 		"json":         {"1"},
 		"type":         {"groupInvite"},
 		"group":        {strconv.FormatUint(uint64(groupID), 10)},
@@ -192,12 +219,6 @@ func (acc *Account) InviteToGroup(groupID GroupID, recipients ...SteamID64) erro
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	content, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
 
 	if string(content) == "" || string(content) == "null" {
 		return errors.New("Failed to invite user(s) to group")
@@ -209,10 +230,7 @@ func (acc *Account) InviteToGroup(groupID GroupID, recipients ...SteamID64) erro
 		Results   string
 	}
 
-	if err := json.Unmarshal(content, &groupInviteResponse); err != nil {
-		if err.Error() == "invalid character '<' looking for beginning of value" {
-			return jsonUnmarshallErrorCheck(content)
-		}
+	if err := unmarshalJSON(content, &groupInviteResponse); err != nil {
 		return err
 	}
 
@@ -223,15 +241,26 @@ func (acc *Account) InviteToGroup(groupID GroupID, recipients ...SteamID64) erro
 	return nil
 }
 
-// ResolveGroupID tried to resolve the GroupID from a group custom url.
+// ResolveGroupID is a wrapper around DefaultClient.ResolveGroupID.
 func ResolveGroupID(groupVanityURL string) (GroupID, error) {
-	resp, err := http.Get("http://steamcommunity.com/groups/" + groupVanityURL + "/memberslistxml?xml=1")
-	if err != nil {
-		return GroupID(0), err
-	}
-	defer resp.Body.Close()
+	return DefaultClient.ResolveGroupID(groupVanityURL)
+}
+
+// ResolveGroupIDContext is a wrapper around DefaultClient.ResolveGroupIDContext.
+func ResolveGroupIDContext(ctx context.Context, groupVanityURL string) (GroupID, error) {
+	return DefaultClient.ResolveGroupIDContext(ctx, groupVanityURL)
+}
+
+// ResolveGroupID tried to resolve the GroupID from a group custom url.
+func (c *Client) ResolveGroupID(groupVanityURL string) (GroupID, error) {
+	return c.ResolveGroupIDContext(context.Background(), groupVanityURL)
+}
 
-	content, err := ioutil.ReadAll(resp.Body)
+// ResolveGroupIDContext is like ResolveGroupID but its requests are bound to ctx.
+func (c *Client) ResolveGroupIDContext(ctx context.Context, groupVanityURL string) (GroupID, error) {
+	content, err := c.get(ctx, c.communityURL("groups/"+groupVanityURL+"/memberslistxml", url.Values{
+		"xml": {"1"},
+	}))
 	if err != nil {
 		return GroupID(0), err
 	}
@@ -245,7 +274,7 @@ func ResolveGroupID(groupVanityURL string) (GroupID, error) {
 		return GroupID(groupid), nil
 	}
 
-	return GroupID(0), errors.New("Unable to resolve groupid")
+	return GroupID(0), ErrNotFound
 }
 
 // StateToString converts a profile state (offline/online/looking to play, etc)
@@ -282,152 +311,52 @@ func StateToString(state int) string {
 
 // ListenAndServe stops execution and loops listening to messages from other Steam
 // users. When a message is received, the argument callback is called.
+//
+// ListenAndServe does not return on errors. As Chat.Run with
+// DefaultChatRetryPolicy, it logs on to the web chat again when its session
+// expires, logs in again if the Account has a Password, and retries other
+// errors forever. It only returns when logging in again is refused or is
+// impossible without a Password.
+//
+// Other chat events are dropped; use Chat to receive them, to limit the
+// retries or to be told of the errors.
 func (acc *Account) ListenAndServe(callback func(user SteamID64, message string)) error {
-	umqid := acc.getUmqid()
-	if umqid == "" {
-		return errors.New("unable to retrieve umqid")
-	}
-
-	acc.Umqid = umqid
-
-	accessToken := acc.getAccessToken()
-	if accessToken == "" {
-		return errors.New("unable to retrieve accessToken")
-	}
-
-	acc.AccessToken = accessToken
-
-	resp, err := acc.HttpClient.Get("https://api.steampowered.com/ISteamWebUserPresenceOAuth/Logon/v0001/?" + url.Values{
-		"jsonp":        {"1"},
-		"ui_mode":      {"web"},
-		"access_token": {acc.AccessToken},
-		"_":            {strconv.FormatInt(makeTimestamp(), 10)},
-	}.Encode())
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	content, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-	content = []byte(string(content)[strings.Index(string(content), `{`) : len(string(content))-1])
-
-	var logonResponse struct {
-		Steamid       string
-		Error         string
-		Umqid         string
-		Timestamp     int64
-		Utc_timestamp int64
-		Message       int
-		Push          int
-	}
-	if err = json.Unmarshal(content, &logonResponse); err != nil {
-		if err.Error() == "invalid character '<' looking for beginning of value" {
-			return jsonUnmarshallErrorCheck(content)
-		}
-		return err
-	}
-
-	if logonResponse.Error != "OK" {
-		return errors.New(logonResponse.Error)
-	}
-
-	acc.Umqid = logonResponse.Umqid
-
-	steamid, err := strconv.ParseInt(logonResponse.Steamid, 10, 64)
-	if err == nil {
-		acc.SteamID = SteamID64(steamid)
-	}
-	var pollid int64 = 1
-	var secttimeout int64 = 20
-	message := int64(logonResponse.Message)
-
-	for {
-		resp, err = acc.HttpClient.Get("https://api.steampowered.com/ISteamWebUserPresenceOAuth/Poll/v0001/?" + url.Values{
-			"jsonp":          {"1"},
-			"umqid":          {acc.Umqid},
-			"message":        {strconv.FormatInt(message, 10)},
-			"pollid":         {strconv.FormatInt(pollid, 10)},
-			"sectimeout":     {strconv.FormatInt(secttimeout, 10)},
-			"secidletime":    {"0"},
-			"use_accountids": {"1"},
-			"access_token":   {acc.AccessToken},
-			"_":              {strconv.FormatInt(makeTimestamp(), 10)},
-		}.Encode())
-		if err != nil {
-			return err
-		}
-
-		content, err = ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return err
-		}
-		content = []byte(string(content)[strings.Index(string(content), `{`) : len(string(content))-1])
-
-		resp.Body.Close()
-
-		var pollResponse struct {
-			Pollid     int64
-			Sectimeout int64
-			Error      string
-			Messages   []struct {
-				Type           string
-				Timestamp      int64
-				Utc_timestamp  int64
-				Accountid_from int64
-				Text           string
-				Status_flags   int
-				Persona_state  int
-				Persona_name   string
-			}
-			Messagelast   int
-			Timestamp     int64
-			Utc_timestamp int64
-			Messagebase   int
-		}
-		if err = json.Unmarshal(content, &pollResponse); err != nil {
-			if err.Error() == "invalid character '<' looking for beginning of value" {
-				return jsonUnmarshallErrorCheck(content)
-			}
-			return err
-		}
-
-		if pollResponse.Error != "OK" && pollResponse.Error != "Timeout" {
-			return errors.New(pollResponse.Error)
-		}
-
-		if pollResponse.Error == "Timeout" {
-			if pollResponse.Sectimeout > 20 {
-				secttimeout = pollResponse.Sectimeout
-			}
+	return acc.ListenAndServeContext(context.Background(), callback)
+}
 
-			if pollResponse.Sectimeout < 120 {
-				if secttimeout+5 < 120 {
-					secttimeout = secttimeout + 5
-				} else {
-					secttimeout = 120
-				}
-			}
+// ListenAndServeContext is like ListenAndServe but its requests are bound to
+// ctx. It also returns ctx.Err() once ctx is done.
+func (acc *Account) ListenAndServeContext(ctx context.Context, callback func(user SteamID64, message string)) error {
+	return acc.Chat(ChatHandlerFunc(func(e ChatEvent) {
+		if e.Type == ChatMessage && len(e.Text) > 0 {
+			callback(e.From, e.Text)
 		}
+	})).Run(ctx)
+}
 
-		for _, message := range pollResponse.Messages {
-			if message.Type == "saytext" && len(message.Text) > 0 {
-				steam64 := SteamID32ToSteamID64(SteamID32(message.Accountid_from))
-				callback(SteamID64(steam64), message.Text)
-			}
-		}
+// SearchForID is a wrapper around DefaultClient.SearchForID using apikey.
+func SearchForID(query, apikey string) SteamID64 {
+	return DefaultClient.withApiKey(apikey).SearchForID(query)
+}
 
-		pollid = pollResponse.Pollid + 1
-		message = int64(pollResponse.Messagelast)
-	}
+// SearchForIDContext is a wrapper around DefaultClient.SearchForIDContext using apikey.
+func SearchForIDContext(ctx context.Context, query, apikey string) SteamID64 {
+	return DefaultClient.withApiKey(apikey).SearchForIDContext(ctx, query)
 }
 
 // SearchForID tries to retrieve a SteamID64 using a query (search).
+// The query may be a profile URL, a SteamID in any format, an s.team invite
+// link or its code (eg. "pgh-rqwp"), a CS:GO friend code or a custom (vanity)
+// URL name. As invite codes can also be vanity URL names, a bare invite code
+// is only used if no vanity URL of that name exists.
 //
 // If an error occurs or the SteamID was unable to be resolved from the query then a 0 is returned.
-func SearchForID(query, apikey string) SteamID64 {
+func (c *Client) SearchForID(query string) SteamID64 {
+	return c.SearchForIDContext(context.Background(), query)
+}
+
+// SearchForIDContext is like SearchForID but its requests are bound to ctx.
+func (c *Client) SearchForIDContext(ctx context.Context, query string) SteamID64 {
 	query = strings.Replace(query, " ", "", -1)
 
 	if strings.Index(query, "steamcommunity.com/profiles/") != -1 {
@@ -454,46 +383,8 @@ func SearchForID(query, apikey string) SteamID64 {
 
 		query = query[strings.Index(query, "steamcommunity.com/id/")+len("steamcommunity.com/id/"):]
 
-		resp, err := http.Get("http://api.steampowered.com/ISteamUser/ResolveVanityURL/v0001/?" + url.Values{
-			"key":       {apikey},
-			"vanityurl": {query},
-		}.Encode())
-		if err != nil {
-			return SteamID64(0)
-		}
-		defer resp.Body.Close()
-
-		content, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return SteamID64(0)
-		}
-
-		var vanityUrlResponse struct {
-			Response struct {
-				Steamid string
-				Success int
-			}
-		}
-
-		if err := json.Unmarshal(content, &vanityUrlResponse); err != nil {
-			return SteamID64(0)
-		}
-
-		if vanityUrlResponse.Response.Success != 1 {
-			return SteamID64(0)
-		}
-
-		if len(vanityUrlResponse.Response.Steamid) != 17 {
-			return SteamID64(0)
-		}
-
-		output, err := strconv.ParseInt(vanityUrlResponse.Response.Steamid, 10, 64)
-		if err != nil {
-			return SteamID64(0)
-		}
-
-		return SteamID64(output)
-	} else if regexp.MustCompile(`^STEAM_0:(0|1):[0-9]{1}[0-9]{0,8}$`).MatchString(query) {
+		return c.resolveVanityURL(ctx, query)
+	} else if regexp.MustCompile(`^STEAM_(0|1):(0|1):[0-9]{1}[0-9]{0,8}$`).MatchString(query) {
 		steam64 := SteamIDToSteamID64(SteamID(query))
 
 		if len(strconv.FormatUint(uint64(steam64), 10)) != 17 {
@@ -513,19 +404,30 @@ func SearchForID(query, apikey string) SteamID64 {
 
 		return SteamID64(output)
 	} else if regexp.MustCompile(`(\[)?U:1:\d+(\])?`).MatchString(strings.ToUpper(query)) {
-		return SteamID3ToSteamID64(SteamID3(query))
+		return SteamID3ToSteamID64(SteamID3(strings.ToUpper(query)))
+	} else if inviteURLPattern.MatchString(query) {
+		return InviteCodeToSteamID64(query)
+	} else if id, err := ParseCSGOFriendCode(query); err == nil {
+		return id.SteamID64()
 	}
 
-	resp, err := http.Get("http://api.steampowered.com/ISteamUser/ResolveVanityURL/v0001/?" + url.Values{
-		"key":       {apikey},
-		"vanityurl": {query},
-	}.Encode())
-	if err != nil {
-		return SteamID64(0)
+	if steam64 := c.resolveVanityURL(ctx, query); steam64 != 0 || !strings.Contains(query, "-") {
+		return steam64
 	}
-	defer resp.Body.Close()
+	if id, err := ParseInviteCode(query); err == nil {
+		return id.SteamID64()
+	}
+	return SteamID64(0)
+}
 
-	content, err := ioutil.ReadAll(resp.Body)
+// resolveVanityURL resolves a custom profile URL name to a SteamID64.
+//
+// 0 is returned if the name could not be resolved.
+func (c *Client) resolveVanityURL(ctx context.Context, vanityURL string) SteamID64 {
+	content, err := c.get(ctx, c.apiURL("ISteamUser/ResolveVanityURL/v0001/", url.Values{
+		"key":       {c.ApiKey},
+		"vanityurl": {vanityURL},
+	}))
 	if err != nil {
 		return SteamID64(0)
 	}
@@ -557,21 +459,30 @@ func SearchForID(query, apikey string) SteamID64 {
 	return SteamID64(output)
 }
 
-// GetPlayerAchievements returns a type PlayerAchievements containing all achievements achieved by a specified SteamID64.
+// GetPlayerAchievements is a wrapper around DefaultClient.GetPlayerAchievements using apikey.
 func GetPlayerAchievements(steam64 SteamID64, appid int, apikey string) (PlayerAchievements, error) {
+	return DefaultClient.withApiKey(apikey).GetPlayerAchievements(steam64, appid)
+}
+
+// GetPlayerAchievementsContext is a wrapper around DefaultClient.GetPlayerAchievementsContext using apikey.
+func GetPlayerAchievementsContext(ctx context.Context, steam64 SteamID64, appid int, apikey string) (PlayerAchievements, error) {
+	return DefaultClient.withApiKey(apikey).GetPlayerAchievementsContext(ctx, steam64, appid)
+}
+
+// GetPlayerAchievements returns a type PlayerAchievements containing all achievements achieved by a specified SteamID64.
+func (c *Client) GetPlayerAchievements(steam64 SteamID64, appid int) (PlayerAchievements, error) {
+	return c.GetPlayerAchievementsContext(context.Background(), steam64, appid)
+}
+
+// GetPlayerAchievementsContext is like GetPlayerAchievements but its requests are bound to ctx.
+func (c *Client) GetPlayerAchievementsContext(ctx context.Context, steam64 SteamID64, appid int) (PlayerAchievements, error) {
 	var plyAchievements PlayerAchievements
 
-	resp, err := http.Get("https://api.steampowered.com/ISteamUserStats/GetPlayerAchievements/v1?" + url.Values{
+	content, err := c.get(ctx, c.apiURL("ISteamUserStats/GetPlayerAchievements/v1", url.Values{
 		"steamid": {strconv.FormatUint(uint64(steam64), 10)},
 		"appid":   {strconv.FormatInt(int64(appid), 10)},
-		"key":     {apikey},
-	}.Encode())
-	if err != nil {
-		return plyAchievements, err
-	}
-	defer resp.Body.Close()
-
-	content, err := ioutil.ReadAll(resp.Body)
+		"key":     {c.ApiKey},
+	}))
 	if err != nil {
 		return plyAchievements, err
 	}
@@ -581,6 +492,7 @@ func GetPlayerAchievements(steam64 SteamID64, appid int, apikey string) (PlayerA
 			SteamID      string
 			GameName     string
 			Success      bool
+			Error        string
 			Achievements []struct {
 				Achieved int
 				Apiname  string
@@ -588,15 +500,15 @@ func GetPlayerAchievements(steam64 SteamID64, appid int, apikey string) (PlayerA
 		}
 	}
 
-	if err = json.Unmarshal(content, &playerAchievementsResponse); err != nil {
-		if err.Error() == "invalid character '<' looking for beginning of value" {
-			return plyAchievements, jsonUnmarshallErrorCheck(content)
-		}
+	if err = unmarshalJSON(content, &playerAchievementsResponse); err != nil {
 		return plyAchievements, err
 	}
 
 	if playerAchievementsResponse.Playerstats.Success != true {
-		return plyAchievements, err
+		if strings.Contains(strings.ToLower(playerAchievementsResponse.Playerstats.Error), "not public") {
+			return plyAchievements, ErrPrivateProfile
+		}
+		return plyAchievements, &ResultError{Result: EResultFail, Message: playerAchievementsResponse.Playerstats.Error}
 	}
 
 	for _, achievement := range playerAchievementsResponse.Playerstats.Achievements {
@@ -614,9 +526,24 @@ func GetPlayerAchievements(steam64 SteamID64, appid int, apikey string) (PlayerA
 	return plyAchievements, nil
 }
 
+// GetPlayersSummaries is a wrapper around DefaultClient.GetPlayersSummaries using apiKey.
+func GetPlayersSummaries(apiKey string, steam64 ...SteamID64) ([]PlayerSummaries, error) {
+	return DefaultClient.withApiKey(apiKey).GetPlayersSummaries(steam64...)
+}
+
+// GetPlayersSummariesContext is a wrapper around DefaultClient.GetPlayersSummariesContext using apiKey.
+func GetPlayersSummariesContext(ctx context.Context, apiKey string, steam64 ...SteamID64) ([]PlayerSummaries, error) {
+	return DefaultClient.withApiKey(apiKey).GetPlayersSummariesContext(ctx, steam64...)
+}
+
 // GetPlayersSummaries returns a slice of PlayerSummaries with the same length of how many valid SteamID64's were parsed
 // as arguments.
-func GetPlayersSummaries(apiKey string, steam64 ...SteamID64) ([]PlayerSummaries, error) {
+func (c *Client) GetPlayersSummaries(steam64 ...SteamID64) ([]PlayerSummaries, error) {
+	return c.GetPlayersSummariesContext(context.Background(), steam64...)
+}
+
+// GetPlayersSummariesContext is like GetPlayersSummaries but its requests are bound to ctx.
+func (c *Client) GetPlayersSummariesContext(ctx context.Context, steam64 ...SteamID64) ([]PlayerSummaries, error) {
 	var plySummaries []PlayerSummaries
 
 	var steamIDs string
@@ -627,16 +554,10 @@ func GetPlayersSummaries(apiKey string, steam64 ...SteamID64) ([]PlayerSummaries
 		}
 	}
 
-	resp, err := http.Get("https://api.steampowered.com/ISteamUser/GetPlayerSummaries/v2/?" + url.Values{
+	content, err := c.get(ctx, c.apiURL("ISteamUser/GetPlayerSummaries/v2/", url.Values{
 		"steamids": {steamIDs},
-		"key":      {apiKey},
-	}.Encode())
-	if err != nil {
-		return plySummaries, err
-	}
-	defer resp.Body.Close()
-
-	content, err := ioutil.ReadAll(resp.Body)
+		"key":      {c.ApiKey},
+	}))
 	if err != nil {
 		return plySummaries, err
 	}
@@ -667,10 +588,7 @@ func GetPlayersSummaries(apiKey string, steam64 ...SteamID64) ([]PlayerSummaries
 		} `json:"response"`
 	}
 
-	if err := json.Unmarshal(content, &playerSummariesResponse); err != nil {
-		if err.Error() == "invalid character '<' looking for beginning of value" {
-			return plySummaries, jsonUnmarshallErrorCheck(content)
-		}
+	if err := unmarshalJSON(content, &playerSummariesResponse); err != nil {
 		return plySummaries, err
 	}
 
@@ -711,20 +629,29 @@ func GetPlayersSummaries(apiKey string, steam64 ...SteamID64) ([]PlayerSummaries
 	return plySummaries, nil
 }
 
-// GetPlayerSummaries returns a PlayerSummaries.
+// GetPlayerSummaries is a wrapper around DefaultClient.GetPlayerSummaries using apiKey.
 func GetPlayerSummaries(apiKey string, steam64 SteamID64) (PlayerSummaries, error) {
+	return DefaultClient.withApiKey(apiKey).GetPlayerSummaries(steam64)
+}
+
+// GetPlayerSummariesContext is a wrapper around DefaultClient.GetPlayerSummariesContext using apiKey.
+func GetPlayerSummariesContext(ctx context.Context, apiKey string, steam64 SteamID64) (PlayerSummaries, error) {
+	return DefaultClient.withApiKey(apiKey).GetPlayerSummariesContext(ctx, steam64)
+}
+
+// GetPlayerSummaries returns a PlayerSummaries.
+func (c *Client) GetPlayerSummaries(steam64 SteamID64) (PlayerSummaries, error) {
+	return c.GetPlayerSummariesContext(context.Background(), steam64)
+}
+
+// GetPlayerSummariesContext is like GetPlayerSummaries but its requests are bound to ctx.
+func (c *Client) GetPlayerSummariesContext(ctx context.Context, steam64 SteamID64) (PlayerSummaries, error) {
 	var plySummaries PlayerSummaries
 
-	resp, err := http.Get("https://api.steampowered.com/ISteamUser/GetPlayerSummaries/v2/?" + url.Values{
+	content, err := c.get(ctx, c.apiURL("ISteamUser/GetPlayerSummaries/v2/", url.Values{
 		"steamids": {strconv.FormatUint(uint64(steam64), 10)},
-		"key":      {apiKey},
-	}.Encode())
-	if err != nil {
-		return plySummaries, err
-	}
-	defer resp.Body.Close()
-
-	content, err := ioutil.ReadAll(resp.Body)
+		"key":      {c.ApiKey},
+	}))
 	if err != nil {
 		return plySummaries, err
 	}
@@ -755,10 +682,7 @@ func GetPlayerSummaries(apiKey string, steam64 SteamID64) (PlayerSummaries, erro
 		} `json:"response"`
 	}
 
-	if err := json.Unmarshal(content, &playerSummariesResponse); err != nil {
-		if err.Error() == "invalid character '<' looking for beginning of value" {
-			return plySummaries, jsonUnmarshallErrorCheck(content)
-		}
+	if err := unmarshalJSON(content, &playerSummariesResponse); err != nil {
 		return plySummaries, err
 	}
 
@@ -795,27 +719,41 @@ func GetPlayerSummaries(apiKey string, steam64 SteamID64) (PlayerSummaries, erro
 			CountryCode:        playerSummariesResponse.Response.Players[0].Loccountrycode,
 		}
 	} else {
-		return plySummaries, errors.New("No player summaries found")
+		return plySummaries, ErrNotFound
 	}
 
 	return plySummaries, nil
 }
 
-// GetFriendsList returns a type FriendsList containing all friends for a specific SteamID64.
+// GetFriendsList is a wrapper around DefaultClient.GetFriendsList using apiKey.
 func GetFriendsList(steam64 SteamID64, apiKey string) (FriendsList, error) {
+	return DefaultClient.withApiKey(apiKey).GetFriendsList(steam64)
+}
+
+// GetFriendsListContext is a wrapper around DefaultClient.GetFriendsListContext using apiKey.
+func GetFriendsListContext(ctx context.Context, steam64 SteamID64, apiKey string) (FriendsList, error) {
+	return DefaultClient.withApiKey(apiKey).GetFriendsListContext(ctx, steam64)
+}
+
+// GetFriendsList returns a type FriendsList containing all friends for a specific SteamID64.
+func (c *Client) GetFriendsList(steam64 SteamID64) (FriendsList, error) {
+	return c.GetFriendsListContext(context.Background(), steam64)
+}
+
+// GetFriendsListContext is like GetFriendsList but its requests are bound to ctx.
+func (c *Client) GetFriendsListContext(ctx context.Context, steam64 SteamID64) (FriendsList, error) {
 	var friends FriendsList
 
-	resp, err := http.Get("https://api.steampowered.com/ISteamUser/GetFriendList/v1/?" + url.Values{
-		"key":     {apiKey},
+	content, err := c.get(ctx, c.apiURL("ISteamUser/GetFriendList/v1/", url.Values{
+		"key":     {c.ApiKey},
 		"steamid": {strconv.FormatUint(uint64(steam64), 10)},
-	}.Encode())
-	if err != nil {
-		return friends, err
-	}
-	defer resp.Body.Close()
-
-	content, err := ioutil.ReadAll(resp.Body)
+	}))
 	if err != nil {
+		// Steam answers 401 Unauthorized for profiles whose friends list is private.
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusUnauthorized {
+			return friends, ErrPrivateProfile
+		}
 		return friends, err
 	}
 
@@ -829,10 +767,7 @@ func GetFriendsList(steam64 SteamID64, apiKey string) (FriendsList, error) {
 		} `json:"friendslist"`
 	}
 
-	if err := json.Unmarshal(content, &friendsListResponse); err != nil {
-		if err.Error() == "invalid character '<' looking for beginning of value" {
-			return friends, jsonUnmarshallErrorCheck(content)
-		}
+	if err := unmarshalJSON(content, &friendsListResponse); err != nil {
 		return friends, err
 	}
 
@@ -852,3 +787,109 @@ func GetFriendsList(steam64 SteamID64, apiKey string) (FriendsList, error) {
 
 	return friends, nil
 }
+
+// ErrInvalidTicket is returned by AuthenticateUserTicket for a ticket which is
+// invalid, expired, cancelled or issued for another app.
+var ErrInvalidTicket = errors.New("steam: invalid ticket")
+
+// A TicketError is returned when Steam refuses to authenticate a ticket.
+type TicketError struct {
+	Code    int // the errorcode of the response, eg. 101 for an invalid ticket
+	Message string
+}
+
+func (e *TicketError) Error() string {
+	return "steam: ticket error " + strconv.Itoa(e.Code) + ": " + e.Message
+}
+
+// Is reports whether target is ErrInvalidTicket and the error is about the
+// ticket rather than the request.
+func (e *TicketError) Is(target error) bool {
+	return target == ErrInvalidTicket && e.Code >= 100
+}
+
+// UserTicket is the owner of an authenticated session ticket.
+type UserTicket struct {
+	SteamID SteamID64
+
+	// OwnerSteamID is the owner of the license of the app, which differs from
+	// SteamID when the app is borrowed through Family Sharing.
+	OwnerSteamID SteamID64
+
+	VACBanned       bool
+	PublisherBanned bool
+}
+
+// AuthenticateUserTicket is a wrapper around DefaultClient.AuthenticateUserTicket using publisherKey.
+func AuthenticateUserTicket(appid int, ticket []byte, publisherKey string) (UserTicket, error) {
+	return DefaultClient.withApiKey(publisherKey).AuthenticateUserTicket(appid, ticket)
+}
+
+// AuthenticateUserTicketContext is a wrapper around DefaultClient.AuthenticateUserTicketContext using publisherKey.
+func AuthenticateUserTicketContext(ctx context.Context, appid int, ticket []byte, publisherKey string) (UserTicket, error) {
+	return DefaultClient.withApiKey(publisherKey).AuthenticateUserTicketContext(ctx, appid, ticket)
+}
+
+// AuthenticateUserTicket authenticates the session ticket a game client got
+// from GetAuthSessionTicket for appid. The Client's ApiKey must be a
+// publisher key of the app.
+func (c *Client) AuthenticateUserTicket(appid int, ticket []byte) (UserTicket, error) {
+	return c.AuthenticateUserTicketContext(context.Background(), appid, ticket)
+}
+
+// AuthenticateUserTicketContext is like AuthenticateUserTicket but its requests are bound to ctx.
+func (c *Client) AuthenticateUserTicketContext(ctx context.Context, appid int, ticket []byte) (UserTicket, error) {
+	var userTicket UserTicket
+
+	// Tickets are single use, so the request bypasses the Cache.
+	req, err := c.newRequest(ctx, "GET", c.partnerURL("ISteamUserAuth/AuthenticateUserTicket/v1/", url.Values{
+		"key":    {c.ApiKey},
+		"appid":  {strconv.Itoa(appid)},
+		"ticket": {hex.EncodeToString(ticket)},
+	}), nil)
+	if err != nil {
+		return userTicket, err
+	}
+	content, err := c.do(c.httpClient(), req)
+	if err != nil {
+		return userTicket, err
+	}
+
+	var ticketResponse struct {
+		Response struct {
+			Params *struct {
+				Result          string `json:"result"`
+				Steamid         string `json:"steamid"`
+				Ownersteamid    string `json:"ownersteamid"`
+				Vacbanned       bool   `json:"vacbanned"`
+				Publisherbanned bool   `json:"publisherbanned"`
+			} `json:"params"`
+			Error *struct {
+				Errorcode int    `json:"errorcode"`
+				Errordesc string `json:"errordesc"`
+			} `json:"error"`
+		} `json:"response"`
+	}
+
+	if err := unmarshalJSON(content, &ticketResponse); err != nil {
+		return userTicket, err
+	}
+
+	if ticketError := ticketResponse.Response.Error; ticketError != nil {
+		return userTicket, &TicketError{Code: ticketError.Errorcode, Message: ticketError.Errordesc}
+	}
+	params := ticketResponse.Response.Params
+	if params == nil || params.Result != "OK" {
+		return userTicket, &ResultError{Result: EResultFail, Message: "ticket not authenticated"}
+	}
+
+	steamID, _ := strconv.ParseUint(params.Steamid, 10, 64)
+	ownerSteamID, _ := strconv.ParseUint(params.Ownersteamid, 10, 64)
+	userTicket = UserTicket{
+		SteamID:         SteamID64(steamID),
+		OwnerSteamID:    SteamID64(ownerSteamID),
+		VACBanned:       params.Vacbanned,
+		PublisherBanned: params.Publisherbanned,
+	}
+	return userTicket, nil
+}
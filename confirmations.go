@@ -0,0 +1,317 @@
+package steam
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ErrNoIdentitySecret is returned by the confirmation methods of an Account
+// without an IdentitySecret.
+var ErrNoIdentitySecret = errors.New("steam: no identity secret")
+
+// ConfirmationType is the kind of action a Confirmation confirms.
+type ConfirmationType int
+
+const (
+	ConfirmationGeneric           ConfirmationType = 1
+	ConfirmationTrade             ConfirmationType = 2
+	ConfirmationMarketListing     ConfirmationType = 3
+	ConfirmationPhoneNumberChange ConfirmationType = 5
+	ConfirmationAccountRecovery   ConfirmationType = 6
+)
+
+// String returns the name of t.
+func (t ConfirmationType) String() string {
+	switch t {
+	case ConfirmationGeneric:
+		return "Generic"
+	case ConfirmationTrade:
+		return "Trade"
+	case ConfirmationMarketListing:
+		return "MarketListing"
+	case ConfirmationPhoneNumberChange:
+		return "PhoneNumberChange"
+	case ConfirmationAccountRecovery:
+		return "AccountRecovery"
+	}
+	return "ConfirmationType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// Confirmation is an action waiting to be confirmed with the Steam Guard
+// mobile authenticator, such as a trade offer or a market listing.
+type Confirmation struct {
+	ID   uint64
+	Key  string // the nonce sent back when accepting or cancelling
+	Type ConfirmationType
+
+	// CreatorID is the ID of the object confirmed: the trade offer ID of a
+	// trade or the listing ID of a market listing.
+	CreatorID uint64
+
+	Headline     string
+	Summary      []string
+	Icon         string // the URL of the icon of the confirmation
+	CreationTime time.Time
+}
+
+// TradeOfferID returns the ID of the trade offer confirmed by c, or 0 if c
+// does not confirm a trade.
+func (c Confirmation) TradeOfferID() uint64 {
+	if c.Type != ConfirmationTrade {
+		return 0
+	}
+	return c.CreatorID
+}
+
+// MarketListingID returns the ID of the market listing confirmed by c, or 0
+// if c does not confirm a market listing.
+func (c Confirmation) MarketListingID() uint64 {
+	if c.Type != ConfirmationMarketListing {
+		return 0
+	}
+	return c.CreatorID
+}
+
+// GenerateConfirmationKey returns the base64 confirmation key for the base64
+// identity_secret of an authenticator, the Steam server time t and the tag
+// of the request (eg. "conf", "allow" or "cancel").
+func GenerateConfirmationKey(identitySecret string, t time.Time, tag string) (string, error) {
+	secret, err := base64.StdEncoding.DecodeString(identitySecret)
+	if err != nil {
+		return "", err
+	}
+
+	// Steam only uses the first 32 bytes of the tag.
+	if len(tag) > 32 {
+		tag = tag[:32]
+	}
+	buf := make([]byte, 8, 8+len(tag))
+	binary.BigEndian.PutUint64(buf, uint64(t.Unix()))
+	buf = append(buf, tag...)
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(buf)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// GenerateDeviceID returns the device ID the Steam mobile app derives from
+// steamID, eg. "android:8c5b1e2a-...".
+func GenerateDeviceID(steamID SteamID64) string {
+	sum := sha1.Sum([]byte(strconv.FormatUint(uint64(steamID), 10)))
+	h := hex.EncodeToString(sum[:])
+	return "android:" + h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// deviceID returns the device ID of the authenticator of acc.
+func (acc *Account) deviceID() string {
+	if acc.DeviceID != "" {
+		return acc.DeviceID
+	}
+	return GenerateDeviceID(acc.steamID())
+}
+
+// confirmationQuery returns the parameters authenticating a confirmation
+// request with tag.
+func (acc *Account) confirmationQuery(ctx context.Context, tag string) (url.Values, error) {
+	if acc.IdentitySecret == "" {
+		return nil, ErrNoIdentitySecret
+	}
+
+	t := acc.client().ServerTimeContext(ctx)
+	key, err := GenerateConfirmationKey(acc.IdentitySecret, t, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return url.Values{
+		"p":   {acc.deviceID()},
+		"a":   {strconv.FormatUint(uint64(acc.steamID()), 10)},
+		"k":   {key},
+		"t":   {strconv.FormatInt(t.Unix(), 10)},
+		"m":   {"react"},
+		"tag": {tag},
+	}, nil
+}
+
+// Confirmations returns the confirmations waiting for acc, which must have
+// an IdentitySecret.
+func (acc *Account) Confirmations() ([]Confirmation, error) {
+	return acc.ConfirmationsContext(context.Background())
+}
+
+// ConfirmationsContext is like Confirmations but its requests are bound to ctx.
+func (acc *Account) ConfirmationsContext(ctx context.Context) ([]Confirmation, error) {
+	var confirmations []Confirmation
+	err := acc.withRelogin(ctx, func() (err error) {
+		confirmations, err = acc.confirmations(ctx)
+		return err
+	})
+	return confirmations, err
+}
+
+// confirmations requests the list of confirmations.
+func (acc *Account) confirmations(ctx context.Context) ([]Confirmation, error) {
+	query, err := acc.confirmationQuery(ctx, "conf")
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := acc.get(ctx, acc.client().communityURL("mobileconf/getlist", query))
+	if err != nil {
+		return nil, err
+	}
+
+	var listResponse struct {
+		Success  bool
+		Needauth bool
+		Message  string
+		Conf     []struct {
+			Type          int
+			Id            string
+			Creator_id    string
+			Nonce         string
+			Creation_time int64
+			Icon          string
+			Headline      string
+			Summary       []string
+		}
+	}
+	if err := unmarshalJSON(content, &listResponse); err != nil {
+		return nil, err
+	}
+
+	if listResponse.Needauth {
+		return nil, ErrSessionExpired
+	}
+	if !listResponse.Success {
+		return nil, confirmationError(listResponse.Message)
+	}
+
+	confirmations := make([]Confirmation, 0, len(listResponse.Conf))
+	for _, conf := range listResponse.Conf {
+		id, _ := strconv.ParseUint(conf.Id, 10, 64)
+		creatorID, _ := strconv.ParseUint(conf.Creator_id, 10, 64)
+		confirmations = append(confirmations, Confirmation{
+			ID:           id,
+			Key:          conf.Nonce,
+			Type:         ConfirmationType(conf.Type),
+			CreatorID:    creatorID,
+			Headline:     conf.Headline,
+			Summary:      conf.Summary,
+			Icon:         conf.Icon,
+			CreationTime: time.Unix(conf.Creation_time, 0),
+		})
+	}
+	return confirmations, nil
+}
+
+// AcceptConfirmation accepts c.
+func (acc *Account) AcceptConfirmation(c Confirmation) error {
+	return acc.AcceptConfirmationsContext(context.Background(), c)
+}
+
+// AcceptConfirmationContext is like AcceptConfirmation but its requests are bound to ctx.
+func (acc *Account) AcceptConfirmationContext(ctx context.Context, c Confirmation) error {
+	return acc.AcceptConfirmationsContext(ctx, c)
+}
+
+// CancelConfirmation cancels c, which declines the trade or removes the
+// market listing it confirms.
+func (acc *Account) CancelConfirmation(c Confirmation) error {
+	return acc.CancelConfirmationsContext(context.Background(), c)
+}
+
+// CancelConfirmationContext is like CancelConfirmation but its requests are bound to ctx.
+func (acc *Account) CancelConfirmationContext(ctx context.Context, c Confirmation) error {
+	return acc.CancelConfirmationsContext(ctx, c)
+}
+
+// AcceptConfirmations accepts confirmations in a single request.
+func (acc *Account) AcceptConfirmations(confirmations ...Confirmation) error {
+	return acc.AcceptConfirmationsContext(context.Background(), confirmations...)
+}
+
+// AcceptConfirmationsContext is like AcceptConfirmations but its requests are bound to ctx.
+func (acc *Account) AcceptConfirmationsContext(ctx context.Context, confirmations ...Confirmation) error {
+	return acc.withRelogin(ctx, func() error {
+		return acc.respondConfirmations(ctx, "allow", confirmations)
+	})
+}
+
+// CancelConfirmations cancels confirmations in a single request.
+func (acc *Account) CancelConfirmations(confirmations ...Confirmation) error {
+	return acc.CancelConfirmationsContext(context.Background(), confirmations...)
+}
+
+// CancelConfirmationsContext is like CancelConfirmations but its requests are bound to ctx.
+func (acc *Account) CancelConfirmationsContext(ctx context.Context, confirmations ...Confirmation) error {
+	return acc.withRelogin(ctx, func() error {
+		return acc.respondConfirmations(ctx, "cancel", confirmations)
+	})
+}
+
+// respondConfirmations applies op ("allow" or "cancel") to confirmations,
+// using ajaxop for a single one and multiajaxop for several.
+func (acc *Account) respondConfirmations(ctx context.Context, op string, confirmations []Confirmation) error {
+	if len(confirmations) == 0 {
+		return nil
+	}
+
+	query, err := acc.confirmationQuery(ctx, op)
+	if err != nil {
+		return err
+	}
+	query.Set("op", op)
+
+	var content []byte
+	if len(confirmations) == 1 {
+		query.Set("cid", strconv.FormatUint(confirmations[0].ID, 10))
+		query.Set("ck", confirmations[0].Key)
+		// ajaxop is a GET, but it answers the confirmation: after a server
+		// error or a timeout it may have been carried out.
+		content, err = acc.get(withRetryMode(ctx, retryRateLimits), acc.client().communityURL("mobileconf/ajaxop", query))
+	} else {
+		for _, c := range confirmations {
+			query.Add("cid[]", strconv.FormatUint(c.ID, 10))
+			query.Add("ck[]", c.Key)
+		}
+		content, err = acc.postForm(ctx, acc.client().communityURL("mobileconf/multiajaxop", nil), query)
+	}
+	if err != nil {
+		return err
+	}
+
+	var opResponse struct {
+		Success  bool
+		Needauth bool
+		Message  string
+	}
+	if err := unmarshalJSON(content, &opResponse); err != nil {
+		return err
+	}
+
+	if opResponse.Needauth {
+		return ErrSessionExpired
+	}
+	if !opResponse.Success {
+		return confirmationError(opResponse.Message)
+	}
+	return nil
+}
+
+// confirmationError returns the error for a failed confirmation request
+// with message.
+func confirmationError(message string) error {
+	if message == "" {
+		return errors.New("steam: confirmation request failed")
+	}
+	return errors.New("steam: " + message)
+}
@@ -0,0 +1,66 @@
+package steam_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Acidic9/steam"
+	"github.com/Acidic9/steam/steamtest"
+)
+
+// identitySecret is the identity_secret of the user "bot" in the tests of
+// confirmations.
+const identitySecret = "aWRlbnRpdHktc2VjcmV0LWJvdA=="
+
+func TestConfirmationRetries(t *testing.T) {
+	srv := steamtest.New()
+	defer srv.Close()
+	srv.AddUser(steamtest.User{Username: "bot", Password: "hunter2", SteamID: botID, IdentitySecret: identitySecret})
+	acc, err := srv.Client().LoginWithOptions("bot", "hunter2", steam.LoginOptions{IdentitySecret: identitySecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ajaxop requests sent since before.
+	ajaxops := func(before int) int {
+		n := 0
+		for _, r := range srv.Requests()[before:] {
+			if r.Path == "/mobileconf/ajaxop" {
+				n++
+			}
+		}
+		return n
+	}
+
+	srv.AddConfirmation(botID, steamtest.Confirmation{Type: steam.ConfirmationTrade, CreatorID: 1})
+	confirmations, err := acc.Confirmations()
+	if err != nil || len(confirmations) != 1 {
+		t.Fatalf("Confirmations = %+v, %v", confirmations, err)
+	}
+
+	// The confirmation may have been answered before the server error, so
+	// it is not retried.
+	before := len(srv.Requests())
+	srv.Inject(steamtest.Fault{Path: "/mobileconf/ajaxop", StatusCode: http.StatusServiceUnavailable, Times: 1})
+	var httpErr *steam.HTTPError
+	if err := acc.AcceptConfirmations(confirmations[0]); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("AcceptConfirmations after a 503: %v, want the 503 *HTTPError", err)
+	}
+	if n := ajaxops(before); n != 1 {
+		t.Errorf("ajaxop sent %d times after a 503, want 1", n)
+	}
+
+	// A rate limited request was refused, so it is retried.
+	before = len(srv.Requests())
+	srv.Inject(steamtest.Fault{Path: "/mobileconf/ajaxop", StatusCode: http.StatusTooManyRequests, Times: 1})
+	if err := acc.AcceptConfirmations(confirmations[0]); err != nil {
+		t.Errorf("AcceptConfirmations after a 429: %v", err)
+	}
+	if n := ajaxops(before); n != 2 {
+		t.Errorf("ajaxop sent %d times after a 429, want 2", n)
+	}
+	if c := srv.Confirmations(botID); len(c) != 1 || !c[0].Accepted {
+		t.Errorf("the confirmation is %+v, want it accepted", c)
+	}
+}
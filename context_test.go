@@ -0,0 +1,60 @@
+package steam_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Acidic9/steam/steamtest"
+)
+
+func TestContextCancellation(t *testing.T) {
+	srv := newServer(t)
+	srv.AddApp(steamtest.App{AppID: 440, Name: "Team Fortress 2", Players: 50000})
+	acc, _ := login(t, srv)
+	client := srv.Client()
+
+	// Every request hangs until it is cancelled.
+	srv.Inject(steamtest.Fault{Delay: time.Minute})
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context) error
+	}{
+		{"GetNumberOfCurrentPlayers", func(ctx context.Context) error {
+			_, err := client.GetNumberOfCurrentPlayersContext(ctx, 440)
+			return err
+		}},
+		{"GetGroupMembers", func(ctx context.Context) error {
+			_, err := client.GetGroupMembersContext(ctx, "bots")
+			return err
+		}},
+		{"Login", func(ctx context.Context) error {
+			_, err := client.LoginContext(ctx, "bot", "hunter2")
+			return err
+		}},
+		{"Message", func(ctx context.Context) error {
+			return acc.MessageContext(ctx, friendIDs[0], "hello")
+		}},
+		{"InviteToGroup", func(ctx context.Context) error {
+			return acc.InviteToGroupContext(ctx, groupID, friendIDs[0])
+		}},
+		{"Broadcast", func(ctx context.Context) error {
+			return acc.BroadcastContext(ctx, "hello")
+		}},
+		{"Relogin", acc.ReloginContext},
+	}
+	for _, test := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		start := time.Now()
+		err := test.call(ctx)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: %v, want context.DeadlineExceeded", test.name, err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("%s returned after %v", test.name, elapsed)
+		}
+	}
+}
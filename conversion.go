@@ -1,7 +1,11 @@
 package steam
 
 import (
-	"math/big"
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -11,13 +15,11 @@ import (
 //
 // 0 is returned if the process was unsuccessful.
 func SteamIDToSteamID64(steamID SteamID) SteamID64 {
-	idParts := strings.Split(string(steamID), ":")
-	magic, _ := new(big.Int).SetString("76561197960265728", 10)
-	steam64, _ := new(big.Int).SetString(idParts[2], 10)
-	steam64 = steam64.Mul(steam64, big.NewInt(2))
-	steam64 = steam64.Add(steam64, magic)
-	auth, _ := new(big.Int).SetString(idParts[1], 10)
-	return SteamID64(steam64.Add(steam64, auth).Int64())
+	id, err := ParseSteamID(string(steamID))
+	if err != nil {
+		return 0
+	}
+	return id.SteamID64()
 }
 
 // SteamIDToSteamID32 converts a given SteamID to a SteamID32.
@@ -25,7 +27,11 @@ func SteamIDToSteamID64(steamID SteamID) SteamID64 {
 //
 // 0 is returned if the process was unsuccessful.
 func SteamIDToSteamID32(steamID SteamID) SteamID32 {
-	return SteamID64ToSteamID32(SteamIDToSteamID64(steamID))
+	id, err := ParseSteamID(string(steamID))
+	if err != nil {
+		return 0
+	}
+	return id.SteamID32()
 }
 
 // SteamIDToSteamID3 converts a given SteamID to a SteamID3.
@@ -33,13 +39,11 @@ func SteamIDToSteamID32(steamID SteamID) SteamID32 {
 //
 // An empty SteamID3 (string) is returned if the process was unsuccessful.
 func SteamIDToSteamID3(steamID SteamID) SteamID3 {
-	steamIDParts := strings.Split(string(steamID), ":")
-	steamLastPart, err := strconv.ParseUint(string(steamIDParts[len(steamIDParts)-1]), 10, 64)
+	id, err := ParseSteamID(string(steamID))
 	if err != nil {
-		return SteamID3("")
+		return ""
 	}
-
-	return SteamID3("[U:1:" + strconv.FormatUint(steamLastPart*2, 10) + "]")
+	return id.SteamID3()
 }
 
 // SteamID64ToSteamID converts a given SteamID64 to a SteamID.
@@ -47,13 +51,7 @@ func SteamIDToSteamID3(steamID SteamID) SteamID3 {
 //
 // An empty SteamID (string) is returned if the process was unsuccessful.
 func SteamID64ToSteamID(steam64 SteamID64) SteamID {
-	steamID := new(big.Int).SetInt64(int64(steam64))
-	magic, _ := new(big.Int).SetString("76561197960265728", 10)
-	steamID = steamID.Sub(steamID, magic)
-	isServer := new(big.Int).And(steamID, big.NewInt(1))
-	steamID = steamID.Sub(steamID, isServer)
-	steamID = steamID.Div(steamID, big.NewInt(2))
-	return SteamID("STEAM_0:" + isServer.String() + ":" + steamID.String())
+	return steam64.ID().SteamID()
 }
 
 // SteamID64ToSteamID32 converts a given SteamID64 to a SteamID32.
@@ -61,15 +59,7 @@ func SteamID64ToSteamID(steam64 SteamID64) SteamID {
 //
 // 0 is returned if the process was unsuccessful.
 func SteamID64ToSteamID32(steam64 SteamID64) SteamID32 {
-	steam64Str := strconv.FormatUint(uint64(steam64), 10)
-	if len(steam64Str) < 3 {
-		return 0
-	}
-	steam32, err := strconv.ParseInt(steam64Str[3:], 10, 64)
-	if err != nil {
-		return 0
-	}
-	return SteamID32(steam32 - 61197960265728)
+	return steam64.ID().SteamID32()
 }
 
 // SteamID64ToSteamID3 converts a given SteamID64 to a SteamID3.
@@ -77,12 +67,7 @@ func SteamID64ToSteamID32(steam64 SteamID64) SteamID32 {
 //
 // An empty SteamID3 (string) is returned if the process was unsuccessful.
 func SteamID64ToSteamID3(steam64 SteamID64) SteamID3 {
-	steamID := SteamID64ToSteamID(steam64)
-	if steamID == SteamID(0) {
-		return SteamID3("")
-	}
-
-	return SteamIDToSteamID3(steamID)
+	return steam64.ID().SteamID3()
 }
 
 // SteamID32ToSteamID converts a given SteamID32 to a SteamID.
@@ -90,7 +75,7 @@ func SteamID64ToSteamID3(steam64 SteamID64) SteamID3 {
 //
 // An empty SteamID (string) is returned if the process was unsuccessful.
 func SteamID32ToSteamID(steam32 SteamID32) SteamID {
-	return SteamID64ToSteamID(SteamID32ToSteamID64(steam32))
+	return steam32.ID().SteamID()
 }
 
 // SteamID32ToSteamID64 converts a given SteamID32 to a SteamID64.
@@ -98,11 +83,7 @@ func SteamID32ToSteamID(steam32 SteamID32) SteamID {
 //
 // 0 is returned if the process was unsuccessful.
 func SteamID32ToSteamID64(steam32 SteamID32) SteamID64 {
-	steam64, err := strconv.ParseInt("765"+strconv.FormatInt(int64(steam32)+61197960265728, 10), 10, 64)
-	if err != nil {
-		return 0
-	}
-	return SteamID64(steam64)
+	return steam32.ID().SteamID64()
 }
 
 // SteamID32ToSteamID3 converts a given SteamID32 to a SteamID3.
@@ -110,12 +91,7 @@ func SteamID32ToSteamID64(steam32 SteamID32) SteamID64 {
 //
 // An empty SteamID3 (string) is returned if the process was unsuccessful.
 func SteamID32ToSteamID3(steam32 SteamID32) SteamID3 {
-	steamID := SteamID32ToSteamID(steam32)
-	if steamID == SteamID(0) {
-		return SteamID3("")
-	}
-
-	return SteamIDToSteamID3(steamID)
+	return steam32.ID().SteamID3()
 }
 
 // SteamID3ToSteamID converts a given SteamID3 to a SteamID.
@@ -123,72 +99,240 @@ func SteamID32ToSteamID3(steam32 SteamID32) SteamID3 {
 //
 // An empty SteamID (string) is returned if the process was unsuccessful.
 func SteamID3ToSteamID(steam3 SteamID3) SteamID {
-	IDparts := strings.Split(string(steam3), ":")
+	id, err := ParseSteamID(string(steam3))
+	if err != nil {
+		return ""
+	}
+	return id.SteamID()
+}
 
-	id32 := IDparts[len(IDparts)-1]
+// SteamID3ToSteamID64 converts a given SteamID3 to a SteamID64.
+// eg. [U:1:172346362] -> 76561198132612090
+//
+// 0 is returned if the process was unsuccessful.
+func SteamID3ToSteamID64(steam3 SteamID3) SteamID64 {
+	id, err := ParseSteamID(string(steam3))
+	if err != nil {
+		return 0
+	}
+	return id.SteamID64()
+}
 
-	if len(id32) <= 0 {
-		return SteamID("")
+// SteamID3ToSteamID32 converts a given SteamID3 to a SteamID32.
+// eg. [U:1:172346362] -> 172346362
+//
+// 0 is returned if the process was unsuccessful.
+func SteamID3ToSteamID32(steam3 SteamID3) SteamID32 {
+	id, err := ParseSteamID(string(steam3))
+	if err != nil {
+		return 0
 	}
+	return id.SteamID32()
+}
 
-	if id32[len(id32)-1:] == "]" {
-		id32 = id32[:len(id32)-1]
+const (
+	inviteCodeAlphabet     = "bcdfghjkmnpqrtvw"
+	csgoFriendCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+var (
+	inviteURLPattern      = regexp.MustCompile(`^(?i)(?:https?://)?s\.team/p/([^/?#]+)(?:[/?#].*)?$`)
+	csgoFriendCodePattern = regexp.MustCompile(`^[A-Z2-9]{5}-[A-Z2-9]{4}$`)
+)
+
+// InviteCode returns the friend code of id used in s.team/p/ invite links.
+// eg. [U:1:172346362] -> pgh-rqwp
+//
+// An empty string is returned if id is not an individual account.
+func (id ID) InviteCode() string {
+	if id.Type != AccountTypeIndividual || id.AccountID == 0 {
+		return ""
 	}
 
-	steam32, err := strconv.ParseUint(id32, 10, 64)
-	if err != nil {
-		return SteamID("")
+	hex := strconv.FormatUint(uint64(id.AccountID), 16)
+	code := make([]byte, len(hex))
+	for i := 0; i < len(hex); i++ {
+		code[i] = inviteCodeAlphabet[strings.IndexByte("0123456789abcdef", hex[i])]
 	}
 
-	return SteamID32ToSteamID(SteamID32(steam32))
+	split := len(code) / 2
+	if split == 0 {
+		return string(code)
+	}
+	return string(code[:split]) + "-" + string(code[split:])
 }
 
-// SteamID3ToSteamID64 converts a given SteamID3 to a SteamID64.
-// eg. [U:1:172346362] -> 76561198132612090
+// InviteURL returns the s.team invite link of id.
+// eg. [U:1:172346362] -> https://s.team/p/pgh-rqwp
 //
-// 0 is returned if the process was unsuccessful.
-func SteamID3ToSteamID64(steam3 SteamID3) SteamID64 {
-	IDparts := strings.Split(string(steam3), ":")
+// An empty string is returned if id is not an individual account.
+func (id ID) InviteURL() string {
+	code := id.InviteCode()
+	if code == "" {
+		return ""
+	}
+	return "https://s.team/p/" + code
+}
 
-	id32 := IDparts[len(IDparts)-1]
+// ParseInviteCode parses a friend code or s.team/p/ invite link and returns
+// the ID of the individual account it refers to.
+//
+// If s cannot be parsed the returned error is a *ParseError.
+func ParseInviteCode(s string) (ID, error) {
+	s = strings.TrimSpace(s)
+	fail := func(err error) (ID, error) {
+		return ID{}, &ParseError{Input: s, Format: "invite code", Err: err}
+	}
 
-	if len(id32) <= 0 {
-		return SteamID64(0)
+	code := s
+	if m := inviteURLPattern.FindStringSubmatch(s); m != nil {
+		code = m[1]
+	}
+	code = strings.Replace(strings.ToLower(code), "-", "", -1)
+	if len(code) == 0 || len(code) > 8 {
+		return fail(ErrUnknownFormat)
 	}
 
-	if id32[len(id32)-1:] == "]" {
-		id32 = id32[:len(id32)-1]
+	var accountID uint32
+	for i := 0; i < len(code); i++ {
+		n := strings.IndexByte(inviteCodeAlphabet, code[i])
+		if n == -1 {
+			return fail(errors.New("invalid character " + strconv.QuoteRune(rune(code[i]))))
+		}
+		accountID = accountID<<4 | uint32(n)
 	}
 
-	steam32, err := strconv.ParseUint(id32, 10, 64)
-	if err != nil {
-		return SteamID64(0)
+	id := SteamID32(accountID).ID()
+	if !id.IsValid() {
+		return fail(ErrInvalidID)
 	}
+	return id, nil
+}
 
-	return SteamID32ToSteamID64(SteamID32(steam32))
+// CSGOFriendCode returns the CS:GO friend code of id.
+// eg. [U:1:172346362] -> SZY7F-WKCJ
+//
+// An empty string is returned if id is not an individual account.
+func (id ID) CSGOFriendCode() string {
+	if id.Type != AccountTypeIndividual || id.AccountID == 0 {
+		return ""
+	}
+
+	hash := csgoFriendCodeHash(id.AccountID)
+	accountID := uint64(id.AccountID)
+
+	var r uint64
+	for i := uint(0); i < 8; i++ {
+		idNibble := uint32(accountID & 0xF)
+		accountID >>= 4
+		hashBit := (hash >> i) & 1
+
+		a := uint32(r<<4) | idNibble
+		r = uint64(uint32(r>>28))<<32 | uint64(a)
+		r = uint64(uint32(r>>31))<<32 | uint64(a<<1|hashBit)
+	}
+	r = bits.ReverseBytes64(r)
+
+	// The code is the base32 encoding of r, minus the leading "AAAA-" which
+	// is the same for every account.
+	code := make([]byte, 0, 15)
+	for i := 0; i < 13; i++ {
+		if i == 4 || i == 9 {
+			code = append(code, '-')
+		}
+		code = append(code, csgoFriendCodeAlphabet[r&0x1F])
+		r >>= 5
+	}
+	return string(code[5:])
 }
 
-// SteamID3ToSteamID64 converts a given SteamID3 to a SteamID64.
-// eg. [U:1:172346362] -> 172346362
+// ParseCSGOFriendCode parses a CS:GO friend code (eg. SZY7F-WKCJ) and returns
+// the ID of the individual account it refers to.
 //
-// 0 is returned if the process was unsuccessful.
-func SteamID3ToSteamID32(steam3 SteamID3) SteamID32 {
-	IDparts := strings.Split(string(steam3), ":")
+// If s cannot be parsed the returned error is a *ParseError.
+func ParseCSGOFriendCode(s string) (ID, error) {
+	s = strings.TrimSpace(s)
+	fail := func(err error) (ID, error) {
+		return ID{}, &ParseError{Input: s, Format: "CS:GO friend code", Err: err}
+	}
 
-	id32 := IDparts[len(IDparts)-1]
+	code := strings.ToUpper(s)
+	if !csgoFriendCodePattern.MatchString(code) {
+		return fail(ErrUnknownFormat)
+	}
+	code = "AAAA" + strings.Replace(code, "-", "", -1)
+
+	var r uint64
+	for i := 0; i < len(code); i++ {
+		r |= uint64(strings.IndexByte(csgoFriendCodeAlphabet, code[i])) << (5 * uint(i))
+	}
+	r = bits.ReverseBytes64(r)
 
-	if len(id32) <= 0 {
-		return SteamID32(0)
+	var accountID uint32
+	for i := 0; i < 8; i++ {
+		r >>= 1
+		accountID = accountID<<4 | uint32(r&0xF)
+		r >>= 4
 	}
 
-	if id32[len(id32)-1:] == "]" {
-		id32 = id32[:len(id32)-1]
+	id := SteamID32(accountID).ID()
+	if !id.IsValid() {
+		return fail(ErrInvalidID)
 	}
+	// Every code carries a few bits of a hash of the account ID; a code
+	// which doesn't re-encode to itself has been mistyped.
+	if id.CSGOFriendCode() != strings.ToUpper(s) {
+		return fail(errors.New("checksum mismatch"))
+	}
+	return id, nil
+}
 
-	steam32, err := strconv.ParseUint(id32, 10, 64)
+// csgoFriendCodeHash returns the hash of an account ID which is mixed into its
+// CS:GO friend code.
+func csgoFriendCodeHash(accountID uint32) uint32 {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(accountID)|uint64(0x4353474F)<<32) // "CSGO"
+	sum := md5.Sum(b[:])
+	return binary.LittleEndian.Uint32(sum[:4])
+}
+
+// SteamID64ToInviteCode converts a given SteamID64 to a s.team/p/ friend code.
+// eg. 76561198132612090 -> pgh-rqwp
+//
+// An empty string is returned if the process was unsuccessful.
+func SteamID64ToInviteCode(steam64 SteamID64) string {
+	return steam64.ID().InviteCode()
+}
+
+// InviteCodeToSteamID64 converts a given s.team/p/ friend code or invite link
+// to a SteamID64.
+// eg. pgh-rqwp -> 76561198132612090
+//
+// 0 is returned if the process was unsuccessful.
+func InviteCodeToSteamID64(code string) SteamID64 {
+	id, err := ParseInviteCode(code)
 	if err != nil {
-		return SteamID32(0)
+		return 0
 	}
+	return id.SteamID64()
+}
+
+// SteamID64ToCSGOFriendCode converts a given SteamID64 to a CS:GO friend code.
+// eg. 76561198132612090 -> SZY7F-WKCJ
+//
+// An empty string is returned if the process was unsuccessful.
+func SteamID64ToCSGOFriendCode(steam64 SteamID64) string {
+	return steam64.ID().CSGOFriendCode()
+}
 
-	return SteamID32(steam32)
+// CSGOFriendCodeToSteamID64 converts a given CS:GO friend code to a SteamID64.
+// eg. SZY7F-WKCJ -> 76561198132612090
+//
+// 0 is returned if the process was unsuccessful.
+func CSGOFriendCodeToSteamID64(code string) SteamID64 {
+	id, err := ParseCSGOFriendCode(code)
+	if err != nil {
+		return 0
+	}
+	return id.SteamID64()
 }
@@ -0,0 +1,378 @@
+package steam
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// The SteamID types implement encoding.TextMarshaler, encoding.TextUnmarshaler,
+// json.Marshaler, json.Unmarshaler, sql.Scanner and driver.Valuer.
+//
+// Every type is encoded in JSON as a string, so 64 bit IDs survive a round trip
+// through JavaScript, and can be decoded from either a JSON string or number.
+// Numeric types are stored in SQL databases as a BIGINT and the string types
+// as text.
+
+// jsonText returns the text of a JSON string or number. ok is false if data
+// is the JSON null value.
+func jsonText(data []byte) (text []byte, ok bool, err error) {
+	if string(data) == "null" {
+		return nil, false, nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, false, err
+		}
+		return []byte(s), true, nil
+	}
+	return data, true, nil
+}
+
+// scanText returns the text or integer value of a database column. ok is
+// false if the value is NULL.
+func scanText(src interface{}) (text []byte, ok bool, err error) {
+	switch src := src.(type) {
+	case nil:
+		return nil, false, nil
+	case int64:
+		// SteamID64s above the range of a BIGINT are stored as negative
+		// numbers by Value.
+		return []byte(strconv.FormatUint(uint64(src), 10)), true, nil
+	case uint64:
+		return []byte(strconv.FormatUint(src, 10)), true, nil
+	case []byte:
+		return src, true, nil
+	case string:
+		return []byte(src), true, nil
+	}
+	return nil, false, fmt.Errorf("steam: cannot scan %T into a SteamID", src)
+}
+
+// parseIDText parses any format accepted by ParseSteamID, so that small
+// numbers are account IDs as they are for ParseSteamID. Decimal numbers too
+// large to be an account ID are decoded as a SteamID64 without validation so
+// that every such ID survives a round trip. Empty text and "0" are the zero
+// value.
+func parseIDText(text []byte) (ID, error) {
+	if len(text) == 0 || string(text) == "0" {
+		return ID{}, nil
+	}
+	if numberPattern.Match(text) {
+		if n, err := strconv.ParseUint(string(text), 10, 64); err == nil && n > 0xFFFFFFFF {
+			return SteamID64(n).ID(), nil
+		}
+	}
+	return ParseSteamID(string(text))
+}
+
+// parseSteamID64Text is like parseIDText but returns a SteamID64.
+func parseSteamID64Text(text []byte) (SteamID64, error) {
+	id, err := parseIDText(text)
+	if err != nil {
+		return 0, err
+	}
+	return id.SteamID64(), nil
+}
+
+// MarshalText encodes steam64 as a decimal number. Values other than 0 which
+// fit in 32 bits are not valid SteamID64s and are decoded back as account IDs,
+// see UnmarshalText.
+func (steam64 SteamID64) MarshalText() ([]byte, error) {
+	return []byte(strconv.FormatUint(uint64(steam64), 10)), nil
+}
+
+// UnmarshalText decodes any format accepted by ParseSteamID, including a decimal
+// SteamID64. As for ParseSteamID, numbers which fit in 32 bits are account IDs.
+// Larger numbers are decoded unchanged, even if they are not valid SteamIDs.
+func (steam64 *SteamID64) UnmarshalText(text []byte) error {
+	n, err := parseSteamID64Text(text)
+	if err != nil {
+		return err
+	}
+	*steam64 = n
+	return nil
+}
+
+// MarshalJSON encodes steam64 as a JSON string.
+func (steam64 SteamID64) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + strconv.FormatUint(uint64(steam64), 10) + `"`), nil
+}
+
+// UnmarshalJSON decodes a SteamID64 from a JSON string or number.
+func (steam64 *SteamID64) UnmarshalJSON(data []byte) error {
+	text, ok, err := jsonText(data)
+	if err != nil || !ok {
+		return err
+	}
+	return steam64.UnmarshalText(text)
+}
+
+// Scan implements sql.Scanner.
+func (steam64 *SteamID64) Scan(src interface{}) error {
+	text, ok, err := scanText(src)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		*steam64 = 0
+		return nil
+	}
+	return steam64.UnmarshalText(text)
+}
+
+// Value implements driver.Valuer.
+func (steam64 SteamID64) Value() (driver.Value, error) {
+	return int64(steam64), nil
+}
+
+// MarshalText encodes groupID as a decimal number.
+func (groupID GroupID) MarshalText() ([]byte, error) {
+	return SteamID64(groupID).MarshalText()
+}
+
+// UnmarshalText decodes the same text as SteamID64.UnmarshalText.
+func (groupID *GroupID) UnmarshalText(text []byte) error {
+	n, err := parseSteamID64Text(text)
+	if err != nil {
+		return err
+	}
+	*groupID = GroupID(n)
+	return nil
+}
+
+// MarshalJSON encodes groupID as a JSON string.
+func (groupID GroupID) MarshalJSON() ([]byte, error) {
+	return SteamID64(groupID).MarshalJSON()
+}
+
+// UnmarshalJSON decodes a GroupID from a JSON string or number.
+func (groupID *GroupID) UnmarshalJSON(data []byte) error {
+	text, ok, err := jsonText(data)
+	if err != nil || !ok {
+		return err
+	}
+	return groupID.UnmarshalText(text)
+}
+
+// Scan implements sql.Scanner.
+func (groupID *GroupID) Scan(src interface{}) error {
+	text, ok, err := scanText(src)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		*groupID = 0
+		return nil
+	}
+	return groupID.UnmarshalText(text)
+}
+
+// Value implements driver.Valuer.
+func (groupID GroupID) Value() (driver.Value, error) {
+	return int64(groupID), nil
+}
+
+// MarshalText encodes steam32 as a decimal number.
+func (steam32 SteamID32) MarshalText() ([]byte, error) {
+	return []byte(strconv.FormatUint(uint64(steam32), 10)), nil
+}
+
+// UnmarshalText decodes a decimal SteamID32 or any format accepted by ParseSteamID.
+func (steam32 *SteamID32) UnmarshalText(text []byte) error {
+	if len(text) == 0 || string(text) == "0" {
+		*steam32 = 0
+		return nil
+	}
+	id, err := ParseSteamID(string(text))
+	if err != nil {
+		return err
+	}
+	*steam32 = id.SteamID32()
+	return nil
+}
+
+// MarshalJSON encodes steam32 as a JSON string.
+func (steam32 SteamID32) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + strconv.FormatUint(uint64(steam32), 10) + `"`), nil
+}
+
+// UnmarshalJSON decodes a SteamID32 from a JSON string or number.
+func (steam32 *SteamID32) UnmarshalJSON(data []byte) error {
+	text, ok, err := jsonText(data)
+	if err != nil || !ok {
+		return err
+	}
+	return steam32.UnmarshalText(text)
+}
+
+// Scan implements sql.Scanner.
+func (steam32 *SteamID32) Scan(src interface{}) error {
+	text, ok, err := scanText(src)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		*steam32 = 0
+		return nil
+	}
+	return steam32.UnmarshalText(text)
+}
+
+// Value implements driver.Valuer.
+func (steam32 SteamID32) Value() (driver.Value, error) {
+	return int64(steam32), nil
+}
+
+// MarshalText returns steamID unchanged.
+func (steamID SteamID) MarshalText() ([]byte, error) {
+	return []byte(steamID), nil
+}
+
+// UnmarshalText decodes any format accepted by ParseSteamID and stores it in
+// the STEAM_X:Y:Z format.
+func (steamID *SteamID) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*steamID = ""
+		return nil
+	}
+	id, err := ParseSteamID(string(text))
+	if err != nil {
+		return err
+	}
+	*steamID = id.SteamID()
+	return nil
+}
+
+// MarshalJSON encodes steamID as a JSON string.
+func (steamID SteamID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(steamID))
+}
+
+// UnmarshalJSON decodes a SteamID from a JSON string or number.
+func (steamID *SteamID) UnmarshalJSON(data []byte) error {
+	text, ok, err := jsonText(data)
+	if err != nil || !ok {
+		return err
+	}
+	return steamID.UnmarshalText(text)
+}
+
+// Scan implements sql.Scanner.
+func (steamID *SteamID) Scan(src interface{}) error {
+	text, ok, err := scanText(src)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		*steamID = ""
+		return nil
+	}
+	return steamID.UnmarshalText(text)
+}
+
+// Value implements driver.Valuer.
+func (steamID SteamID) Value() (driver.Value, error) {
+	return string(steamID), nil
+}
+
+// MarshalText returns steam3 unchanged.
+func (steam3 SteamID3) MarshalText() ([]byte, error) {
+	return []byte(steam3), nil
+}
+
+// UnmarshalText decodes any format accepted by ParseSteamID and stores it in
+// the SteamID3 format.
+func (steam3 *SteamID3) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*steam3 = ""
+		return nil
+	}
+	id, err := ParseSteamID(string(text))
+	if err != nil {
+		return err
+	}
+	*steam3 = id.SteamID3()
+	return nil
+}
+
+// MarshalJSON encodes steam3 as a JSON string.
+func (steam3 SteamID3) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(steam3))
+}
+
+// UnmarshalJSON decodes a SteamID3 from a JSON string or number.
+func (steam3 *SteamID3) UnmarshalJSON(data []byte) error {
+	text, ok, err := jsonText(data)
+	if err != nil || !ok {
+		return err
+	}
+	return steam3.UnmarshalText(text)
+}
+
+// Scan implements sql.Scanner.
+func (steam3 *SteamID3) Scan(src interface{}) error {
+	text, ok, err := scanText(src)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		*steam3 = ""
+		return nil
+	}
+	return steam3.UnmarshalText(text)
+}
+
+// Value implements driver.Valuer.
+func (steam3 SteamID3) Value() (driver.Value, error) {
+	return string(steam3), nil
+}
+
+// MarshalText encodes id as a decimal SteamID64.
+func (id ID) MarshalText() ([]byte, error) {
+	return id.SteamID64().MarshalText()
+}
+
+// UnmarshalText decodes the same text as SteamID64.UnmarshalText.
+func (id *ID) UnmarshalText(text []byte) error {
+	parsed, err := parseIDText(text)
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// MarshalJSON encodes id as a JSON string containing a decimal SteamID64.
+func (id ID) MarshalJSON() ([]byte, error) {
+	return id.SteamID64().MarshalJSON()
+}
+
+// UnmarshalJSON decodes an ID from a JSON string or number.
+func (id *ID) UnmarshalJSON(data []byte) error {
+	text, ok, err := jsonText(data)
+	if err != nil || !ok {
+		return err
+	}
+	return id.UnmarshalText(text)
+}
+
+// Scan implements sql.Scanner.
+func (id *ID) Scan(src interface{}) error {
+	text, ok, err := scanText(src)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		*id = ID{}
+		return nil
+	}
+	return id.UnmarshalText(text)
+}
+
+// Value implements driver.Valuer. The ID is stored as a SteamID64.
+func (id ID) Value() (driver.Value, error) {
+	return int64(id.SteamID64()), nil
+}
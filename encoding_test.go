@@ -0,0 +1,194 @@
+package steam
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// encodingIDs are values of every SteamID type, in their canonical form.
+var encodingIDs = []interface{}{
+	SteamID64(76561198132612090),
+	GroupID(103582791453729676),
+	SteamID32(172346362),
+	SteamID("STEAM_0:0:86173181"),
+	SteamID3("[U:1:172346362]"),
+	SteamID64(76561198132612090).ID(),
+	GroupID(103582791453729676).ID(),
+}
+
+// newLike returns a pointer to a new zero value of the type of v.
+func newLike(v interface{}) interface{} {
+	return reflect.New(reflect.TypeOf(v)).Interface()
+}
+
+func TestTextRoundTrip(t *testing.T) {
+	for _, v := range encodingIDs {
+		text, err := v.(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			t.Errorf("%T(%v).MarshalText: %v", v, v, err)
+			continue
+		}
+		p := newLike(v)
+		if err := p.(encoding.TextUnmarshaler).UnmarshalText(text); err != nil {
+			t.Errorf("%T.UnmarshalText(%q): %v", v, text, err)
+			continue
+		}
+		if got := reflect.ValueOf(p).Elem().Interface(); got != v {
+			t.Errorf("%T: text round trip of %v gave %v", v, v, got)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	for _, v := range encodingIDs {
+		data, err := json.Marshal(map[string]interface{}{"id": v})
+		if err != nil {
+			t.Errorf("json.Marshal(%T(%v)): %v", v, v, err)
+			continue
+		}
+		p := newLike(v)
+		wrapper := struct{ ID interface{} }{ID: p}
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			t.Errorf("json.Unmarshal(%s) into %T: %v", data, v, err)
+			continue
+		}
+		if got := reflect.ValueOf(p).Elem().Interface(); got != v {
+			t.Errorf("%T: JSON round trip of %v through %s gave %v", v, v, data, got)
+		}
+	}
+}
+
+func TestJSONNumbersAndNull(t *testing.T) {
+	var steam64 SteamID64
+	if err := json.Unmarshal([]byte(`76561198132612090`), &steam64); err != nil || steam64 != 76561198132612090 {
+		t.Errorf("unmarshal number = %v, %v", steam64, err)
+	}
+	if err := json.Unmarshal([]byte(`null`), &steam64); err != nil || steam64 != 76561198132612090 {
+		t.Errorf("unmarshal null changed the value to %v, %v", steam64, err)
+	}
+}
+
+func TestSQLRoundTrip(t *testing.T) {
+	for _, v := range encodingIDs {
+		value, err := v.(driver.Valuer).Value()
+		if err != nil {
+			t.Errorf("%T(%v).Value: %v", v, v, err)
+			continue
+		}
+		p := newLike(v)
+		if err := p.(sql.Scanner).Scan(value); err != nil {
+			t.Errorf("%T.Scan(%v): %v", v, value, err)
+			continue
+		}
+		if got := reflect.ValueOf(p).Elem().Interface(); got != v {
+			t.Errorf("%T: SQL round trip of %v through %v gave %v", v, v, value, got)
+		}
+
+		if err := p.(sql.Scanner).Scan(nil); err != nil {
+			t.Errorf("%T.Scan(nil): %v", v, err)
+		}
+		if got := reflect.ValueOf(p).Elem().Interface(); got != reflect.Zero(reflect.TypeOf(v)).Interface() {
+			t.Errorf("%T.Scan(nil) gave %v, want the zero value", v, got)
+		}
+	}
+}
+
+func TestZeroRoundTrip(t *testing.T) {
+	var steam64 SteamID64
+	data, _ := json.Marshal(steam64)
+	steam64 = 1
+	if err := json.Unmarshal(data, &steam64); err != nil || steam64 != 0 {
+		t.Errorf("round trip of the zero SteamID64 through %s = %v, %v", data, steam64, err)
+	}
+}
+
+func TestUnmarshalTextMatchesParseSteamID(t *testing.T) {
+	for _, s := range []string{"172346362", "76561198132612090", "STEAM_0:0:86173181", "[U:1:172346362]"} {
+		id, err := ParseSteamID(s)
+		if err != nil {
+			t.Fatalf("ParseSteamID(%q): %v", s, err)
+		}
+
+		var steam64 SteamID64
+		if err := steam64.UnmarshalText([]byte(s)); err != nil {
+			t.Errorf("SteamID64.UnmarshalText(%q): %v", s, err)
+		} else if steam64 != id.SteamID64() {
+			t.Errorf("SteamID64.UnmarshalText(%q) = %v, ParseSteamID gives %v", s, steam64, id.SteamID64())
+		}
+
+		var steam32 SteamID32
+		if err := steam32.UnmarshalText([]byte(s)); err != nil {
+			t.Errorf("SteamID32.UnmarshalText(%q): %v", s, err)
+		} else if steam32 != id.SteamID32() {
+			t.Errorf("SteamID32.UnmarshalText(%q) = %v, ParseSteamID gives %v", s, steam32, id.SteamID32())
+		}
+
+		var parsed ID
+		if err := parsed.UnmarshalText([]byte(s)); err != nil {
+			t.Errorf("ID.UnmarshalText(%q): %v", s, err)
+		} else if parsed != id {
+			t.Errorf("ID.UnmarshalText(%q) = %+v, ParseSteamID gives %+v", s, parsed, id)
+		}
+	}
+}
+
+func TestSteamID64Boundaries(t *testing.T) {
+	for _, v := range []SteamID64{0, 0xFFFFFFFF + 1, 76561197960265728, 76561198132612090, 1<<63 + 5, 1<<64 - 1} {
+		var values []interface{}
+		for _, v := range []interface{}{v, GroupID(v), v.ID()} {
+			text, _ := v.(encoding.TextMarshaler).MarshalText()
+			data, _ := json.Marshal(v)
+			value, _ := v.(driver.Valuer).Value()
+
+			p := newLike(v)
+			if err := p.(encoding.TextUnmarshaler).UnmarshalText(text); err != nil {
+				t.Errorf("%T.UnmarshalText(%q): %v", v, text, err)
+			}
+			values = append(values, reflect.ValueOf(p).Elem().Interface())
+			p = newLike(v)
+			if err := json.Unmarshal(data, p); err != nil {
+				t.Errorf("json.Unmarshal(%s) into %T: %v", data, v, err)
+			}
+			values = append(values, reflect.ValueOf(p).Elem().Interface())
+			p = newLike(v)
+			if err := p.(sql.Scanner).Scan(value); err != nil {
+				t.Errorf("%T.Scan(%v): %v", v, value, err)
+			}
+			values = append(values, reflect.ValueOf(p).Elem().Interface())
+
+			for _, got := range values {
+				if got != v {
+					t.Errorf("%T(%v) round trip gave %v", v, v, got)
+				}
+			}
+			values = values[:0]
+		}
+	}
+}
+
+func TestSteamID64AndIDDecodeAlike(t *testing.T) {
+	for _, s := range []string{"", "0", "5", "4294967295", "4294967296", "76561198132612090", "18446744073709551615",
+		"18446744073709551616", "-1", "STEAM_0:0:86173181", "[U:1:172346362]", "[g:1:4777282]", "nobody"} {
+		var steam64 SteamID64
+		err64 := steam64.UnmarshalText([]byte(s))
+		var id ID
+		errID := id.UnmarshalText([]byte(s))
+		if (err64 == nil) != (errID == nil) {
+			t.Errorf("%q: SteamID64 error %v, ID error %v", s, err64, errID)
+		} else if err64 == nil && steam64 != id.SteamID64() {
+			t.Errorf("%q: SteamID64 %v, ID %+v", s, steam64, id)
+		}
+	}
+
+	// Numbers which fit in 32 bits are account IDs, so SteamID64s below
+	// 2^32 are not kept.
+	data, _ := json.Marshal(SteamID64(5))
+	var steam64 SteamID64
+	if err := json.Unmarshal(data, &steam64); err != nil || steam64 != SteamID32(5).ID().SteamID64() {
+		t.Errorf("SteamID64(5) decoded as %v, %v, want the SteamID64 of account 5", steam64, err)
+	}
+}
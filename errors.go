@@ -0,0 +1,219 @@
+package steam
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors returned by the Steam API functions. Use errors.Is to check for them,
+// as they are often wrapped in an *HTTPError or a *ResultError.
+var (
+	// ErrRateLimited is returned when Steam refuses a request because too
+	// many have been made (HTTP 429 or EResult RateLimitExceeded).
+	ErrRateLimited = errors.New("steam: rate limited")
+
+	// ErrUnauthorized is returned when Steam refuses a request because the
+	// API key is missing or invalid (HTTP 401 or 403).
+	ErrUnauthorized = errors.New("steam: unauthorized")
+
+	// ErrPrivateProfile is returned when the requested information belongs to
+	// a profile which is not public.
+	ErrPrivateProfile = errors.New("steam: profile is private")
+
+	// ErrNotFound is returned when the requested user, group or app does not
+	// exist.
+	ErrNotFound = errors.New("steam: not found")
+
+	// ErrSessionExpired is returned when the session of an Account is no
+	// longer logged in.
+	ErrSessionExpired = errors.New("steam: session expired")
+)
+
+// Errors returned when logging in fails.
+var (
+	// ErrInvalidCredentials is returned when the username or password is
+	// wrong.
+	ErrInvalidCredentials = errors.New("steam: invalid username or password")
+
+	// ErrTwoFactorRequired is returned when Steam asks for a Steam Guard
+	// mobile code and there is no way to get one.
+	ErrTwoFactorRequired = errors.New("steam: Steam Guard mobile code required")
+
+	// ErrTwoFactorRejected is returned when Steam refuses the Steam Guard
+	// mobile codes it was given.
+	ErrTwoFactorRejected = errors.New("steam: Steam Guard mobile code rejected")
+
+	// ErrEmailAuthRequired is returned when Steam asks for a Steam Guard code
+	// sent by email and there is no way to get one.
+	ErrEmailAuthRequired = errors.New("steam: Steam Guard email code required")
+
+	// ErrEmailAuthRejected is returned when Steam refuses the Steam Guard
+	// email codes it was given.
+	ErrEmailAuthRejected = errors.New("steam: Steam Guard email code rejected")
+
+	// ErrCaptchaRequired is returned when Steam asks for a captcha to be
+	// solved and there is no solver.
+	ErrCaptchaRequired = errors.New("steam: captcha required")
+
+	// ErrCaptchaRejected is returned when Steam refuses the captcha answers
+	// it was given.
+	ErrCaptchaRejected = errors.New("steam: captcha rejected")
+)
+
+// EResult is a result code returned by Steam.
+type EResult int
+
+const (
+	EResultInvalid                         EResult = 0
+	EResultOK                              EResult = 1
+	EResultFail                            EResult = 2
+	EResultNoConnection                    EResult = 3
+	EResultInvalidPassword                 EResult = 5
+	EResultLoggedInElsewhere               EResult = 6
+	EResultInvalidProtocolVer              EResult = 7
+	EResultInvalidParam                    EResult = 8
+	EResultFileNotFound                    EResult = 9
+	EResultBusy                            EResult = 10
+	EResultInvalidState                    EResult = 11
+	EResultInvalidName                     EResult = 12
+	EResultInvalidEmail                    EResult = 13
+	EResultDuplicateName                   EResult = 14
+	EResultAccessDenied                    EResult = 15
+	EResultTimeout                         EResult = 16
+	EResultBanned                          EResult = 17
+	EResultAccountNotFound                 EResult = 18
+	EResultInvalidSteamID                  EResult = 19
+	EResultServiceUnavailable              EResult = 20
+	EResultNotLoggedOn                     EResult = 21
+	EResultPending                         EResult = 22
+	EResultEncryptionFailure               EResult = 23
+	EResultInsufficientPrivilege           EResult = 24
+	EResultLimitExceeded                   EResult = 25
+	EResultRevoked                         EResult = 26
+	EResultExpired                         EResult = 27
+	EResultAlreadyRedeemed                 EResult = 28
+	EResultDuplicateRequest                EResult = 29
+	EResultNoMatch                         EResult = 42
+	EResultAccountLogonDenied              EResult = 63
+	EResultInvalidLoginAuthCode            EResult = 65
+	EResultRateLimitExceeded               EResult = 84
+	EResultAccountLoginDeniedNeedTwoFactor EResult = 85
+	EResultTwoFactorCodeMismatch           EResult = 88
+)
+
+var eresultNames = map[EResult]string{
+	EResultInvalid:                         "Invalid",
+	EResultOK:                              "OK",
+	EResultFail:                            "Fail",
+	EResultNoConnection:                    "NoConnection",
+	EResultInvalidPassword:                 "InvalidPassword",
+	EResultLoggedInElsewhere:               "LoggedInElsewhere",
+	EResultInvalidProtocolVer:              "InvalidProtocolVer",
+	EResultInvalidParam:                    "InvalidParam",
+	EResultFileNotFound:                    "FileNotFound",
+	EResultBusy:                            "Busy",
+	EResultInvalidState:                    "InvalidState",
+	EResultInvalidName:                     "InvalidName",
+	EResultInvalidEmail:                    "InvalidEmail",
+	EResultDuplicateName:                   "DuplicateName",
+	EResultAccessDenied:                    "AccessDenied",
+	EResultTimeout:                         "Timeout",
+	EResultBanned:                          "Banned",
+	EResultAccountNotFound:                 "AccountNotFound",
+	EResultInvalidSteamID:                  "InvalidSteamID",
+	EResultServiceUnavailable:              "ServiceUnavailable",
+	EResultNotLoggedOn:                     "NotLoggedOn",
+	EResultPending:                         "Pending",
+	EResultEncryptionFailure:               "EncryptionFailure",
+	EResultInsufficientPrivilege:           "InsufficientPrivilege",
+	EResultLimitExceeded:                   "LimitExceeded",
+	EResultRevoked:                         "Revoked",
+	EResultExpired:                         "Expired",
+	EResultAlreadyRedeemed:                 "AlreadyRedeemed",
+	EResultDuplicateRequest:                "DuplicateRequest",
+	EResultNoMatch:                         "NoMatch",
+	EResultAccountLogonDenied:              "AccountLogonDenied",
+	EResultInvalidLoginAuthCode:            "InvalidLoginAuthCode",
+	EResultRateLimitExceeded:               "RateLimitExceeded",
+	EResultAccountLoginDeniedNeedTwoFactor: "AccountLoginDeniedNeedTwoFactor",
+	EResultTwoFactorCodeMismatch:           "TwoFactorCodeMismatch",
+}
+
+// String returns the name of the result code.
+func (r EResult) String() string {
+	if name, ok := eresultNames[r]; ok {
+		return name
+	}
+	return "EResult(" + strconv.Itoa(int(r)) + ")"
+}
+
+// A ResultError is returned when Steam answers a request with an EResult
+// other than OK.
+type ResultError struct {
+	Result  EResult
+	Message string // the message returned alongside the result, if any
+}
+
+func (e *ResultError) Error() string {
+	if e.Message == "" {
+		return "steam: " + e.Result.String()
+	}
+	return "steam: " + e.Result.String() + ": " + e.Message
+}
+
+// Is reports whether the result matches one of the package's sentinel errors.
+func (e *ResultError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.Result == EResultRateLimitExceeded
+	case ErrUnauthorized:
+		return e.Result == EResultAccessDenied
+	case ErrNotFound:
+		return e.Result == EResultFileNotFound || e.Result == EResultNoMatch || e.Result == EResultAccountNotFound
+	case ErrSessionExpired:
+		return e.Result == EResultNotLoggedOn
+	}
+	return false
+}
+
+// An HTTPError is returned when Steam answers a request with an error status
+// code or with an HTML error page where JSON was expected.
+type HTTPError struct {
+	StatusCode int
+	Message    string        // the text of the error page, if any
+	RetryAfter time.Duration // the delay asked for by a Retry-After header, if any
+}
+
+func (e *HTTPError) Error() string {
+	msg := "steam: " + strconv.Itoa(e.StatusCode) + " " + http.StatusText(e.StatusCode)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Is reports whether the status code matches one of the package's sentinel
+// errors.
+func (e *HTTPError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// presenceError returns the error for a message other than "OK" returned by
+// the ISteamWebUserPresenceOAuth interface.
+func presenceError(message string) error {
+	if strings.EqualFold(message, "Not Logged On") {
+		return ErrSessionExpired
+	}
+	return errors.New("steam: " + message)
+}
@@ -0,0 +1,96 @@
+package steam_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Acidic9/steam"
+	"github.com/Acidic9/steam/steamtest"
+)
+
+func TestErrors(t *testing.T) {
+	const (
+		playersPath      = "/ISteamUserStats/GetNumberOfCurrentPlayers/"
+		achievementsPath = "/ISteamUserStats/GetPlayerAchievements/"
+		privateID        = steam.SteamID64(76561198000000100)
+		missingID        = steam.SteamID64(76561198000000200)
+	)
+	getPlayers := func(c *steam.Client) error {
+		_, err := c.GetNumberOfCurrentPlayers(440)
+		return err
+	}
+	getAchievements := func(c *steam.Client) error {
+		_, err := c.GetPlayerAchievements(botID, 440)
+		return err
+	}
+	sentinels := []error{steam.ErrRateLimited, steam.ErrUnauthorized, steam.ErrPrivateProfile, steam.ErrNotFound, steam.ErrSessionExpired}
+
+	tests := []struct {
+		name   string
+		fault  *steamtest.Fault
+		call   func(c *steam.Client) error
+		is     error         // the sentinel matched, if any
+		status int           // the status of the *HTTPError, 0 if it is none
+		result steam.EResult // the result of the *ResultError, 0 if it is none
+	}{
+		{"429", &steamtest.Fault{Path: playersPath, StatusCode: http.StatusTooManyRequests}, getPlayers, steam.ErrRateLimited, http.StatusTooManyRequests, 0},
+		{"401", &steamtest.Fault{Path: playersPath, StatusCode: http.StatusUnauthorized}, getPlayers, steam.ErrUnauthorized, http.StatusUnauthorized, 0},
+		{"404", &steamtest.Fault{Path: playersPath, StatusCode: http.StatusNotFound}, getPlayers, steam.ErrNotFound, http.StatusNotFound, 0},
+		{"503", &steamtest.Fault{Path: playersPath, StatusCode: http.StatusServiceUnavailable}, getPlayers, nil, http.StatusServiceUnavailable, 0},
+		{"wrong key", nil, func(c *steam.Client) error {
+			c.ApiKey = "wrong"
+			_, err := c.GetPlayerSummaries(botID)
+			return err
+		}, steam.ErrUnauthorized, http.StatusForbidden, 0},
+		{"RateLimitExceeded", &steamtest.Fault{Path: playersPath, StatusCode: http.StatusOK, Body: `{"response":{"result":84}}`}, getPlayers, steam.ErrRateLimited, 0, steam.EResultRateLimitExceeded},
+		{"AccessDenied", &steamtest.Fault{Path: playersPath, StatusCode: http.StatusOK, Body: `{"response":{"result":15}}`}, getPlayers, steam.ErrUnauthorized, 0, steam.EResultAccessDenied},
+		{"NoMatch", &steamtest.Fault{Path: playersPath, StatusCode: http.StatusOK, Body: `{"response":{"result":42}}`}, getPlayers, steam.ErrNotFound, 0, steam.EResultNoMatch},
+		{"Fail", &steamtest.Fault{Path: playersPath, StatusCode: http.StatusOK, Body: `{"response":{"result":2}}`}, getPlayers, nil, 0, steam.EResultFail},
+		{"private friends list", nil, func(c *steam.Client) error {
+			_, err := c.GetFriendsList(privateID)
+			return err
+		}, steam.ErrPrivateProfile, 0, 0},
+		{"private achievements", &steamtest.Fault{Path: achievementsPath, StatusCode: http.StatusOK, Body: `{"playerstats":{"error":"Profile is not public","success":false}}`}, getAchievements, steam.ErrPrivateProfile, 0, 0},
+		{"achievements error", &steamtest.Fault{Path: achievementsPath, StatusCode: http.StatusOK, Body: `{"playerstats":{"error":"Requested app has no stats","success":false}}`}, getAchievements, nil, 0, steam.EResultFail},
+		{"missing player", nil, func(c *steam.Client) error {
+			_, err := c.GetPlayerSummaries(missingID)
+			return err
+		}, steam.ErrNotFound, 0, 0},
+		{"missing group", nil, func(c *steam.Client) error {
+			_, err := c.ResolveGroupID("nobody")
+			return err
+		}, steam.ErrNotFound, 0, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := newServer(t)
+			srv.AddApp(steamtest.App{AppID: 440, Name: "Team Fortress 2", Players: 50000})
+			srv.AddPlayer(steamtest.Player{SteamID: privateID, PersonaName: "private", Private: true})
+			if test.fault != nil {
+				srv.Inject(*test.fault)
+			}
+			client := srv.Client()
+			client.Retry = steam.RetryPolicy{MaxRetries: -1}
+
+			err := test.call(client)
+			if err == nil {
+				t.Fatal("no error")
+			}
+			for _, sentinel := range sentinels {
+				if errors.Is(err, sentinel) != (sentinel == test.is) {
+					t.Errorf("errors.Is(%v, %v) = %v", err, sentinel, !(sentinel == test.is))
+				}
+			}
+
+			var httpErr *steam.HTTPError
+			if errors.As(err, &httpErr) != (test.status != 0) || test.status != 0 && httpErr.StatusCode != test.status {
+				t.Errorf("%v is not an *HTTPError of status %d", err, test.status)
+			}
+			var resultErr *steam.ResultError
+			if errors.As(err, &resultErr) != (test.result != 0) || test.result != 0 && resultErr.Result != test.result {
+				t.Errorf("%v is not a *ResultError of result %v", err, test.result)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package steam_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Acidic9/steam"
+	"github.com/Acidic9/steam/steamtest"
+)
+
+const (
+	botID   = steam.SteamID64(76561198000000001)
+	groupID = steam.GroupID(103582791453729676)
+)
+
+// friendIDs are the friends of the user "bot".
+var friendIDs = func() []steam.SteamID64 {
+	ids := make([]steam.SteamID64, 10)
+	for i := range ids {
+		ids[i] = botID + 1 + steam.SteamID64(i)
+	}
+	return ids
+}()
+
+// newServer returns a Server with the user "bot", password "hunter2", who has
+// the friends friendIDs, and the group groupID.
+func newServer(t *testing.T) *steamtest.Server {
+	srv := steamtest.New()
+	t.Cleanup(srv.Close)
+	srv.PollTimeout = 50 * time.Millisecond
+
+	srv.AddUser(steamtest.User{Username: "bot", Password: "hunter2", SteamID: botID})
+	bot := steamtest.Player{SteamID: botID, PersonaName: "bot"}
+	for _, id := range friendIDs {
+		bot.Friends = append(bot.Friends, steamtest.Friend{SteamID: id})
+		srv.AddPlayer(steamtest.Player{SteamID: id, PersonaName: "friend"})
+	}
+	srv.AddPlayer(bot)
+	srv.AddGroup(steamtest.Group{ID: groupID, Name: "bots", Members: []steam.SteamID64{botID}})
+	return srv
+}
+
+// login logs "bot" into srv. The messages of the Account are not rate
+// limited, and the returned counter counts its relogins.
+func login(t *testing.T, srv *steamtest.Server) (*steam.Account, *int32) {
+	acc, err := srv.Client().Login("bot", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlimited := steam.RateLimit{Rate: 1000, Burst: 1000}
+	acc.MessageQueue = &steam.MessageQueue{Account: acc, RateLimit: unlimited, RecipientRateLimit: unlimited}
+
+	relogins := new(int32)
+	acc.OnRelogin = func(cause, err error) {
+		if err != nil {
+			t.Errorf("relogin after %v: %v", cause, err)
+		}
+		atomic.AddInt32(relogins, 1)
+	}
+	return acc, relogins
+}
+
+// waitState waits for states to receive state.
+func waitState(t *testing.T, states <-chan steam.ChatState, state steam.ChatState) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case s := <-states:
+			if s == state {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("the chat did not become %v", state)
+		}
+	}
+}
+
+// startChat runs a Chat of acc until the test ends, once it is online. It
+// returns the channels receiving the events and states of the Chat.
+func startChat(t *testing.T, acc *steam.Account) (<-chan steam.ChatEvent, <-chan steam.ChatState) {
+	events := make(chan steam.ChatEvent, 100)
+	states := serveChat(t, acc, steam.ChatHandlerFunc(func(e steam.ChatEvent) {
+		events <- e
+	}))
+	return events, states
+}
+
+// serveChat runs a Chat of acc passing the events to handler until the test
+// ends, once it is online. It returns the channel receiving the states of
+// the Chat.
+func serveChat(t *testing.T, acc *steam.Account, handler steam.ChatHandler) <-chan steam.ChatState {
+	states := make(chan steam.ChatState, 100)
+	chat := acc.Chat(handler)
+	chat.OnState = func(state steam.ChatState, err error) {
+		states <- state
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- chat.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Errorf("Run returned %v, want context.Canceled", err)
+		}
+	})
+
+	waitState(t, states, steam.ChatOnline)
+	return states
+}
+
+// waitMessage waits for events to receive the message text from from.
+func waitMessage(t *testing.T, events <-chan steam.ChatEvent, from steam.SteamID64, text string) {
+	t.Helper()
+	select {
+	case e := <-events:
+		if e.Type != steam.ChatMessage || e.From != from || e.Text != text {
+			t.Errorf("received %+v, want the message %q from %v", e, text, from)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the message %q was not received", text)
+	}
+}
@@ -4,19 +4,24 @@
 package steam
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"html"
-	"io/ioutil"
 	"net/http"
-	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
 // Account is a struct containing information about a Steam account including
 // the login details and http client.
+//
+// An Account is safe for concurrent use once logged in. Its SteamID, Umqid,
+// AccessToken and HttpClient are updated by the Account itself, so while it is
+// in use they should be read with Session rather than directly.
 type Account struct {
 	Username    string
 	Password    string
@@ -25,6 +30,44 @@ type Account struct {
 	Umqid       string
 	AccessToken string
 	ApiKey      string
+
+	// SharedSecret is the base64 shared_secret of the Steam Guard mobile
+	// authenticator of the account, used to answer two-factor challenges.
+	SharedSecret string
+
+	// IdentitySecret is the base64 identity_secret of the Steam Guard mobile
+	// authenticator of the account, used to answer confirmations.
+	IdentitySecret string
+
+	// DeviceID is the device ID of the mobile authenticator, eg.
+	// "android:...". If empty, it is derived from SteamID as the Steam
+	// mobile app does.
+	DeviceID string
+
+	// Client provides the base URLs and request settings used by the
+	// Account. If nil, DefaultClient is used.
+	Client *Client
+
+	// MessageQueue paces the messages sent by Message and Broadcast. If nil,
+	// a MessageQueue with the default limits is created on first use.
+	MessageQueue *MessageQueue
+
+	// OnRelogin, if set, is called after the Account logged in again because
+	// its session expired. cause is the error which revealed the expired
+	// session and err the result of the relogin.
+	OnRelogin func(cause, err error)
+
+	// loginOptions are the options of the login which created the Account,
+	// reused by Relogin.
+	loginOptions LoginOptions
+
+	// lastTransfer holds the session credentials of the last login.
+	lastTransfer *LoginTransfer
+
+	mu     sync.Mutex // guards SteamID, Umqid, AccessToken, HttpClient and lastTransfer
+	chatMu sync.Mutex // held while logging on to the web chat
+
+	relogin reloginState
 }
 
 type SteamID string   // STEAM_0:0:86173181
@@ -35,14 +78,8 @@ type GroupID uint64   // 103582791453729676
 
 // getSessionId returns the Steam sessionid cookie.
 // If no sessionid cookie is found, an empty string will be returned.
-func (acc *Account) getSessionId() (string, error) {
-	resp, err := acc.HttpClient.Get("https://steamcommunity.com/")
-	defer resp.Body.Close()
-	if err != nil {
-		return "", err
-	}
-
-	content, err := ioutil.ReadAll(resp.Body)
+func (acc *Account) getSessionId(ctx context.Context) (string, error) {
+	content, err := acc.get(ctx, acc.client().communityURL("", nil))
 	if err != nil {
 		return "", err
 	}
@@ -56,64 +93,93 @@ func (acc *Account) getSessionId() (string, error) {
 }
 
 // getAccessToken returns the accesstoken of an Account.
-// If no accesstoken is found then an empty string is returned.
-func (acc *Account) getAccessToken() string {
-	resp, err := acc.HttpClient.Get("https://steamcommunity.com/chat")
-	if err != nil {
-		return ""
-	}
-	defer resp.Body.Close()
-
-	content, err := ioutil.ReadAll(resp.Body)
+// An error is returned if no accesstoken is found.
+func (acc *Account) getAccessToken(ctx context.Context) (string, error) {
+	content, err := acc.get(ctx, acc.client().communityURL("chat", nil))
 	if err != nil {
-		return ""
+		return "", err
 	}
 
 	tokenSlice := regexp.MustCompile(`CWebAPI\s*\(\s*(?:[^,]+,){2}\s*"([0-9a-f]{32})"\s*\)`).FindSubmatch(content)
 	if len(tokenSlice) < 2 {
-		return ""
+		return "", errors.New("unable to retrieve accessToken")
 	}
 
-	return string(tokenSlice[1])
+	return string(tokenSlice[1]), nil
 }
 
-// getUmqid returns the umqid of an Account.
-// If no accessToken is found then an empty string is returned.
-func (acc *Account) getUmqid() string {
-	accessToken := acc.getAccessToken()
-	if accessToken == "" {
-		return ""
-	}
+// chatCredentials returns the umqid and access token of acc, logging on to
+// the web chat on first use. Concurrent callers wait for a single logon.
+func (acc *Account) chatCredentials(ctx context.Context) (umqid, accessToken string, err error) {
+	acc.chatMu.Lock()
+	defer acc.chatMu.Unlock()
 
-	resp, err := acc.HttpClient.PostForm("https://api.steampowered.com/ISteamWebUserPresenceOAuth/Logon/v0001", url.Values{
-		"jsonp":        []string{"1"},
-		"ui_mode":      []string{"web"},
-		"access_token": []string{accessToken},
-	})
-	if err != nil {
-		return ""
+	acc.mu.Lock()
+	umqid, accessToken = acc.Umqid, acc.AccessToken
+	acc.mu.Unlock()
+	if umqid != "" && accessToken != "" {
+		return umqid, accessToken, nil
 	}
-	defer resp.Body.Close()
 
-	content, err := ioutil.ReadAll(resp.Body)
+	logon, err := acc.chatLogon(ctx, "")
 	if err != nil {
-		return ""
+		return "", "", err
 	}
+	return logon.umqid, logon.accessToken, nil
+}
 
-	var umqidResponse struct {
-		Error         string
-		Message       int
-		Push          int
-		Steamid       string
-		Timestamp     int64
-		Umqid         string
-		Utc_timestamp int64
+// renewChat logs acc on to the web chat again, resuming the umqid expired
+// which Steam no longer accepts, unless another caller replaced it already.
+// It returns the new credentials.
+func (acc *Account) renewChat(ctx context.Context, expired string) (umqid, accessToken string, err error) {
+	acc.chatMu.Lock()
+	defer acc.chatMu.Unlock()
+
+	acc.mu.Lock()
+	umqid, accessToken = acc.Umqid, acc.AccessToken
+	acc.mu.Unlock()
+	if umqid != "" && umqid != expired && accessToken != "" {
+		return umqid, accessToken, nil
 	}
-	if err := json.Unmarshal(content, &umqidResponse); err != nil {
-		return ""
+
+	logon, err := acc.chatLogon(ctx, expired)
+	if err != nil {
+		return "", "", err
 	}
+	return logon.umqid, logon.accessToken, nil
+}
+
+// resetChat forgets the chat credentials of acc, which belong to a session
+// that ended. It waits for a logon in progress, whose credentials belong to
+// the session too.
+func (acc *Account) resetChat() {
+	acc.chatMu.Lock()
+	defer acc.chatMu.Unlock()
+
+	acc.mu.Lock()
+	acc.Umqid, acc.AccessToken = "", ""
+	acc.mu.Unlock()
+}
+
+// steamID returns the SteamID of acc.
+func (acc *Account) steamID() SteamID64 {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	return acc.SteamID
+}
+
+// setSteamID sets the SteamID of acc.
+func (acc *Account) setSteamID(steamID SteamID64) {
+	acc.mu.Lock()
+	acc.SteamID = steamID
+	acc.mu.Unlock()
+}
 
-	return umqidResponse.Umqid
+// httpClient returns the HttpClient of acc.
+func (acc *Account) httpClient() *http.Client {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	return acc.HttpClient
 }
 
 // makeTimestamp returns the current Unix timestamp.
@@ -134,15 +200,50 @@ func stringBetween(str, start, end string) (string, error) {
 	return str[:strings.Index(str, end)], nil
 }
 
-// jsonUnmarshallError is used to manage steam errors which are no json and return the message given.
-func jsonUnmarshallErrorCheck(content []byte) error {
+// unmarshalJSON decodes the JSON response content into v.
+// If Steam responded with an HTML error page an *HTTPError holding the
+// message of the page is returned.
+func unmarshalJSON(content []byte, v interface{}) error {
+	if err := json.Unmarshal(content, v); err != nil {
+		if isHTML(content) {
+			return &HTTPError{StatusCode: http.StatusOK, Message: htmlErrorMessage(content)}
+		}
+		return err
+	}
+	return nil
+}
+
+// unwrapJSONP returns the JSON inside a JSONP response such as `1({...})`,
+// as sent by the ISteamWebUserPresenceOAuth interface when the jsonp parameter
+// is set. Other content is returned unchanged.
+func unwrapJSONP(content []byte) []byte {
+	trimmed := bytes.TrimSpace(content)
+	start := bytes.IndexByte(trimmed, '(')
+	if start < 0 || bytes.HasPrefix(trimmed, []byte("{")) || bytes.HasPrefix(trimmed, []byte("[")) {
+		return content
+	}
+	trimmed = bytes.TrimSuffix(trimmed, []byte(";"))
+	if !bytes.HasSuffix(trimmed, []byte(")")) {
+		return content
+	}
+	return trimmed[start+1 : len(trimmed)-1]
+}
+
+// isHTML reports whether content looks like an HTML page rather than JSON.
+func isHTML(content []byte) bool {
+	trimmed := strings.TrimSpace(string(content))
+	return strings.HasPrefix(trimmed, "<")
+}
+
+// htmlErrorMessage returns the text of a Steam error page.
+func htmlErrorMessage(content []byte) string {
 	var errorPage string
 	if strings.Index(strings.ToLower(string(content)), "<body>") == -1 {
-		return errors.New(html.UnescapeString(string(content)))
+		return html.UnescapeString(strings.TrimSpace(string(content)))
 	}
 	errorPage = string(content)[len("<body>")+strings.Index(strings.ToLower(string(content)), "<body>"):]
 	if strings.Index(strings.ToLower(string(content)), "</body>") == -1 {
-		return errors.New(html.UnescapeString(string(content)))
+		return html.UnescapeString(strings.TrimSpace(string(content)))
 	}
 	errorPage = errorPage[:strings.Index(strings.ToLower(errorPage), "</body>")]
 
@@ -160,5 +261,5 @@ func jsonUnmarshallErrorCheck(content []byte) error {
 		}
 	}
 
-	return errors.New(html.UnescapeString(strings.Replace(errorPage, "\n", " ", -1)))
+	return html.UnescapeString(strings.TrimSpace(strings.Replace(errorPage, "\n", " ", -1)))
 }
@@ -1,21 +1,33 @@
 package steam
 
 import (
+	"context"
 	"encoding/xml"
-	"io/ioutil"
-	"net/http"
+	"net/url"
 )
 
+// GetGroupMembers is a wrapper around DefaultClient.GetGroupMembers.
+func GetGroupMembers(groupName string) ([]SteamID64, error) {
+	return DefaultClient.GetGroupMembers(groupName)
+}
+
+// GetGroupMembersContext is a wrapper around DefaultClient.GetGroupMembersContext.
+func GetGroupMembersContext(ctx context.Context, groupName string) ([]SteamID64, error) {
+	return DefaultClient.GetGroupMembersContext(ctx, groupName)
+}
+
 // GetGroupMembers uses a group url name (http://steamcommunity.com/groups/GOLANG) and returns a slice of
 // the group members.
-func GetGroupMembers(groupName string) ([]SteamID64, error) {
-	resp, err := http.Get("http://steamcommunity.com/groups/" + groupName + "/memberslistxml?json=1&xml=1")
-	if err != nil {
-		return []SteamID64{}, err
-	}
-	defer resp.Body.Close()
+func (c *Client) GetGroupMembers(groupName string) ([]SteamID64, error) {
+	return c.GetGroupMembersContext(context.Background(), groupName)
+}
 
-	body, err := ioutil.ReadAll(resp.Body)
+// GetGroupMembersContext is like GetGroupMembers but its requests are bound to ctx.
+func (c *Client) GetGroupMembersContext(ctx context.Context, groupName string) ([]SteamID64, error) {
+	body, err := c.get(ctx, c.communityURL("groups/"+groupName+"/memberslistxml", url.Values{
+		"json": {"1"},
+		"xml":  {"1"},
+	}))
 	if err != nil {
 		return []SteamID64{}, err
 	}
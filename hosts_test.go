@@ -0,0 +1,72 @@
+package steam_test
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Acidic9/steam"
+	"github.com/Acidic9/steam/steamtest"
+)
+
+// countingTransport counts the requests sent through it.
+type countingTransport struct {
+	requests int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.requests, 1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClientHosts(t *testing.T) {
+	// Every server can answer every request, so only the base URLs decide
+	// which one does.
+	ticket := []byte{0x14, 0x00, 0x00, 0x00, 0xde, 0xad, 0xbe, 0xef}
+	var servers [3]*steamtest.Server
+	for i := range servers {
+		servers[i] = newServer(t)
+		servers[i].AddApp(steamtest.App{AppID: 440, Name: "Team Fortress 2", Players: 50000})
+		servers[i].AddTicket(steamtest.Ticket{AppID: 440, Ticket: ticket, SteamID: botID})
+	}
+	api, community, partner := servers[0], servers[1], servers[2]
+
+	transport := new(countingTransport)
+	client := &steam.Client{
+		HttpClient:       &http.Client{Transport: transport},
+		ApiBaseURL:       api.URL,
+		CommunityBaseURL: community.URL,
+		PartnerBaseURL:   partner.URL,
+		ApiKey:           steamtest.DefaultPublisherKey,
+		Limiter:          steam.NewLimiter(steam.RateLimit{}),
+	}
+
+	if _, err := client.GetNumberOfCurrentPlayers(440); err != nil {
+		t.Errorf("GetNumberOfCurrentPlayers: %v", err)
+	}
+	if _, err := client.GetGroupMembers("bots"); err != nil {
+		t.Errorf("GetGroupMembers: %v", err)
+	}
+	if _, err := client.AuthenticateUserTicket(440, ticket); err != nil {
+		t.Errorf("AuthenticateUserTicket: %v", err)
+	}
+
+	for _, test := range []struct {
+		name string
+		srv  *steamtest.Server
+		path string
+	}{
+		{"API", api, "/ISteamUserStats/GetNumberOfCurrentPlayers/"},
+		{"community", community, "/groups/bots/memberslistxml"},
+		{"partner", partner, "/ISteamUserAuth/AuthenticateUserTicket/"},
+	} {
+		requests := test.srv.Requests()
+		if len(requests) != 1 || !strings.HasPrefix(requests[0].Path, test.path) {
+			t.Errorf("the %s server received %+v, want one request of %s", test.name, requests, test.path)
+		}
+	}
+	if n := atomic.LoadInt32(&transport.requests); n != 3 {
+		t.Errorf("%d requests sent through the HttpClient, want 3", n)
+	}
+}
@@ -0,0 +1,454 @@
+package steam
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Universe is the Steam universe (realm) an ID belongs to.
+type Universe uint8
+
+const (
+	UniverseInvalid  Universe = 0
+	UniversePublic   Universe = 1
+	UniverseBeta     Universe = 2
+	UniverseInternal Universe = 3
+	UniverseDev      Universe = 4
+)
+
+// String returns the name of the universe.
+func (u Universe) String() string {
+	switch u {
+	case UniverseInvalid:
+		return "Invalid"
+	case UniversePublic:
+		return "Public"
+	case UniverseBeta:
+		return "Beta"
+	case UniverseInternal:
+		return "Internal"
+	case UniverseDev:
+		return "Dev"
+	}
+	return "Universe(" + strconv.FormatUint(uint64(u), 10) + ")"
+}
+
+// AccountType is the type of account an ID refers to, such as an individual
+// user, a group (clan) or a game server.
+type AccountType uint8
+
+const (
+	AccountTypeInvalid        AccountType = 0
+	AccountTypeIndividual     AccountType = 1
+	AccountTypeMultiseat      AccountType = 2
+	AccountTypeGameServer     AccountType = 3
+	AccountTypeAnonGameServer AccountType = 4
+	AccountTypePending        AccountType = 5
+	AccountTypeContentServer  AccountType = 6
+	AccountTypeClan           AccountType = 7
+	AccountTypeChat           AccountType = 8
+	AccountTypeConsoleUser    AccountType = 9
+	AccountTypeAnonUser       AccountType = 10
+)
+
+// String returns the name of the account type.
+func (t AccountType) String() string {
+	switch t {
+	case AccountTypeInvalid:
+		return "Invalid"
+	case AccountTypeIndividual:
+		return "Individual"
+	case AccountTypeMultiseat:
+		return "Multiseat"
+	case AccountTypeGameServer:
+		return "GameServer"
+	case AccountTypeAnonGameServer:
+		return "AnonGameServer"
+	case AccountTypePending:
+		return "Pending"
+	case AccountTypeContentServer:
+		return "ContentServer"
+	case AccountTypeClan:
+		return "Clan"
+	case AccountTypeChat:
+		return "Chat"
+	case AccountTypeConsoleUser:
+		return "ConsoleUser"
+	case AccountTypeAnonUser:
+		return "AnonUser"
+	}
+	return "AccountType(" + strconv.FormatUint(uint64(t), 10) + ")"
+}
+
+// Instance values used by individual accounts and the flags carried in the
+// instance of chat IDs.
+const (
+	InstanceAll     uint32 = 0
+	InstanceDesktop uint32 = 1
+	InstanceConsole uint32 = 2
+	InstanceWeb     uint32 = 4
+
+	ChatInstanceFlagClan  uint32 = 0x80000
+	ChatInstanceFlagLobby uint32 = 0x40000
+)
+
+// ID is a decoded Steam ID. Every textual and numeric SteamID format can be
+// rendered from an ID without losing the universe, account type or instance.
+//
+// The zero value is an invalid ID.
+type ID struct {
+	Universe  Universe
+	Type      AccountType
+	Instance  uint32 // only the lower 20 bits are used
+	AccountID uint32
+}
+
+// ID decodes all 64 bits of a SteamID64.
+// eg. 76561198132612090 -> {Public Individual 1 172346362}
+func (steam64 SteamID64) ID() ID {
+	return ID{
+		Universe:  Universe(steam64 >> 56),
+		Type:      AccountType(steam64 >> 52 & 0xF),
+		Instance:  uint32(steam64 >> 32 & 0xFFFFF),
+		AccountID: uint32(steam64),
+	}
+}
+
+// ID returns the ID of an individual desktop user in the public universe
+// with the account ID steam32.
+func (steam32 SteamID32) ID() ID {
+	return ID{
+		Universe:  UniversePublic,
+		Type:      AccountTypeIndividual,
+		Instance:  InstanceDesktop,
+		AccountID: uint32(steam32),
+	}
+}
+
+// ID decodes all 64 bits of a GroupID.
+func (groupID GroupID) ID() ID {
+	return SteamID64(groupID).ID()
+}
+
+// IsValid reports whether id has a known universe and account type, and an
+// account ID and instance that make sense for that type.
+func (id ID) IsValid() bool {
+	if id.Universe == UniverseInvalid || id.Universe > UniverseDev {
+		return false
+	}
+	if id.Instance > 0xFFFFF {
+		return false
+	}
+
+	switch id.Type {
+	case AccountTypeIndividual:
+		return id.AccountID != 0 && id.Instance <= InstanceWeb
+	case AccountTypeClan:
+		return id.AccountID != 0 && id.Instance == InstanceAll
+	case AccountTypeGameServer:
+		return id.AccountID != 0
+	case AccountTypeMultiseat, AccountTypeAnonGameServer, AccountTypePending,
+		AccountTypeContentServer, AccountTypeChat, AccountTypeConsoleUser, AccountTypeAnonUser:
+		return true
+	}
+	return false
+}
+
+// SteamID64 encodes id as a SteamID64.
+// eg. {Public Individual 1 172346362} -> 76561198132612090
+func (id ID) SteamID64() SteamID64 {
+	return SteamID64(uint64(id.Universe)<<56 |
+		uint64(id.Type&0xF)<<52 |
+		uint64(id.Instance&0xFFFFF)<<32 |
+		uint64(id.AccountID))
+}
+
+// SteamID32 returns the account ID of id.
+// eg. {Public Individual 1 172346362} -> 172346362
+func (id ID) SteamID32() SteamID32 {
+	return SteamID32(id.AccountID)
+}
+
+// SteamID renders id in the STEAM_X:Y:Z format. The public universe is
+// rendered as STEAM_0 as it is by most Source engine games.
+// eg. {Public Individual 1 172346362} -> STEAM_0:0:86173181
+func (id ID) SteamID() SteamID {
+	universe := uint64(id.Universe)
+	if id.Universe == UniversePublic {
+		universe = 0
+	}
+	return SteamID("STEAM_" + strconv.FormatUint(universe, 10) +
+		":" + strconv.FormatUint(uint64(id.AccountID&1), 10) +
+		":" + strconv.FormatUint(uint64(id.AccountID>>1), 10))
+}
+
+// SteamID3 renders id in the [T:U:A] format where T is a letter identifying
+// the account type. The instance is appended as [T:U:A:I] when it differs
+// from the one ParseSteamID assumes for the letter.
+// eg. {Public Individual 1 172346362} -> [U:1:172346362], {Public Clan 0 4777282} -> [g:1:4777282]
+func (id ID) SteamID3() SteamID3 {
+	var letter string
+	withInstance := false
+
+	switch id.Type {
+	case AccountTypeIndividual:
+		letter = "U"
+		withInstance = id.Instance != InstanceDesktop
+	case AccountTypeMultiseat:
+		letter = "M"
+		withInstance = true
+	case AccountTypeGameServer:
+		letter = "G"
+		withInstance = id.Instance != InstanceDesktop
+	case AccountTypeAnonGameServer:
+		letter = "A"
+		withInstance = true
+	case AccountTypePending:
+		letter = "P"
+		withInstance = id.Instance != InstanceDesktop
+	case AccountTypeContentServer:
+		letter = "C"
+		withInstance = id.Instance != InstanceDesktop
+	case AccountTypeClan:
+		letter = "g"
+	case AccountTypeChat:
+		switch {
+		case id.Instance&ChatInstanceFlagClan != 0:
+			letter = "c"
+		case id.Instance&ChatInstanceFlagLobby != 0:
+			letter = "L"
+		default:
+			letter = "T"
+		}
+	case AccountTypeAnonUser:
+		letter = "a"
+		withInstance = id.Instance != InstanceDesktop
+	case AccountTypeInvalid:
+		letter = "I"
+	default:
+		letter = "i"
+	}
+
+	steam3 := "[" + letter + ":" + strconv.FormatUint(uint64(id.Universe), 10) + ":" + strconv.FormatUint(uint64(id.AccountID), 10)
+	if withInstance {
+		steam3 += ":" + strconv.FormatUint(uint64(id.Instance), 10)
+	}
+	return SteamID3(steam3 + "]")
+}
+
+// String returns id in the SteamID3 format.
+func (id ID) String() string {
+	return string(id.SteamID3())
+}
+
+var (
+	// ErrUnknownFormat is returned by ParseSteamID when the input does not
+	// match any known SteamID format.
+	ErrUnknownFormat = errors.New("unrecognised SteamID format")
+
+	// ErrOutOfRange is returned by ParseSteamID when a component of the input
+	// is too large for its field.
+	ErrOutOfRange = errors.New("value out of range")
+
+	// ErrInvalidID is returned by ParseSteamID when the input is well formed
+	// but does not describe a valid ID.
+	ErrInvalidID = errors.New("not a valid SteamID")
+
+	// ErrVanityURL is returned by ParseSteamID for custom (vanity) profile URLs,
+	// which can only be resolved through the Web API. See SearchForID.
+	ErrVanityURL = errors.New("vanity URLs must be resolved through the Web API")
+)
+
+// A ParseError records a failed attempt to parse a SteamID.
+type ParseError struct {
+	Input  string // the string being parsed
+	Format string // the format the input was detected as, if any
+	Err    error  // the reason the parse failed
+}
+
+func (e *ParseError) Error() string {
+	if e.Format == "" {
+		return "steam: parsing " + strconv.Quote(e.Input) + ": " + e.Err.Error()
+	}
+	return "steam: parsing " + strconv.Quote(e.Input) + " as " + e.Format + ": " + e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	steamIDPattern    = regexp.MustCompile(`^(?i)STEAM_([0-9]+):([0-9]+):([0-9]+)$`)
+	steamID3Pattern   = regexp.MustCompile(`^(?:\[([A-Za-z]):([0-9]+):([0-9]+)(?::([0-9]+))?\]|([A-Za-z]):([0-9]+):([0-9]+)(?::([0-9]+))?)$`)
+	numberPattern     = regexp.MustCompile(`^[0-9]+$`)
+	profileURLPattern = regexp.MustCompile(`^(?i)(?:https?://)?(?:www\.)?steamcommunity\.com/(profiles|id)/([^/?#]+)/?(?:[?#].*)?$`)
+)
+
+// ParseSteamID parses a SteamID in any of the following formats:
+//
+//	STEAM_0:0:86173181 (STEAM_1:0:86173181 is also accepted)
+//	[U:1:172346362]
+//	172346362
+//	76561198132612090
+//	https://steamcommunity.com/profiles/76561198132612090
+//
+// Bare numbers which fit in 32 bits are treated as the account ID of an
+// individual user in the public universe.
+//
+// If s cannot be parsed the returned error is a *ParseError.
+func ParseSteamID(s string) (ID, error) {
+	s = strings.TrimSpace(s)
+
+	if m := profileURLPattern.FindStringSubmatch(s); m != nil {
+		if strings.ToLower(m[1]) == "id" {
+			return ID{}, &ParseError{Input: s, Format: "profile URL", Err: ErrVanityURL}
+		}
+		id, err := ParseSteamID(m[2])
+		if err != nil {
+			return ID{}, &ParseError{Input: s, Format: "profile URL", Err: errors.Unwrap(err)}
+		}
+		return id, nil
+	}
+
+	if m := steamIDPattern.FindStringSubmatch(s); m != nil {
+		return parseSteamID(s, m)
+	}
+
+	if m := steamID3Pattern.FindStringSubmatch(s); m != nil {
+		// Move the submatches of an ID without brackets to where those of
+		// one with brackets are.
+		if m[1] == "" {
+			m = append(m[:1], m[5:]...)
+		}
+		return parseSteamID3(s, m)
+	}
+
+	if numberPattern.MatchString(s) {
+		return parseSteamIDNumber(s)
+	}
+
+	return ID{}, &ParseError{Input: s, Err: ErrUnknownFormat}
+}
+
+// parseSteamID parses the submatches of steamIDPattern.
+func parseSteamID(s string, m []string) (ID, error) {
+	fail := func(err error) (ID, error) {
+		return ID{}, &ParseError{Input: s, Format: "SteamID", Err: err}
+	}
+
+	universe, err := strconv.ParseUint(m[1], 10, 8)
+	if err != nil {
+		return fail(ErrOutOfRange)
+	}
+	// STEAM_0 and STEAM_1 both refer to the public universe.
+	if universe == 0 {
+		universe = uint64(UniversePublic)
+	}
+
+	y, err := strconv.ParseUint(m[2], 10, 1)
+	if err != nil {
+		return fail(ErrOutOfRange)
+	}
+
+	z, err := strconv.ParseUint(m[3], 10, 31)
+	if err != nil {
+		return fail(ErrOutOfRange)
+	}
+
+	id := ID{
+		Universe:  Universe(universe),
+		Type:      AccountTypeIndividual,
+		Instance:  InstanceDesktop,
+		AccountID: uint32(z<<1 | y),
+	}
+	if !id.IsValid() {
+		return fail(ErrInvalidID)
+	}
+	return id, nil
+}
+
+// parseSteamID3 parses the submatches of steamID3Pattern.
+func parseSteamID3(s string, m []string) (ID, error) {
+	fail := func(err error) (ID, error) {
+		return ID{}, &ParseError{Input: s, Format: "SteamID3", Err: err}
+	}
+
+	var id ID
+	switch m[1] {
+	case "U":
+		id.Type, id.Instance = AccountTypeIndividual, InstanceDesktop
+	case "M":
+		id.Type, id.Instance = AccountTypeMultiseat, InstanceDesktop
+	case "G":
+		id.Type, id.Instance = AccountTypeGameServer, InstanceDesktop
+	case "A":
+		id.Type, id.Instance = AccountTypeAnonGameServer, InstanceDesktop
+	case "P":
+		id.Type, id.Instance = AccountTypePending, InstanceDesktop
+	case "C":
+		id.Type, id.Instance = AccountTypeContentServer, InstanceDesktop
+	case "g":
+		id.Type, id.Instance = AccountTypeClan, InstanceAll
+	case "T":
+		id.Type, id.Instance = AccountTypeChat, InstanceAll
+	case "c":
+		id.Type, id.Instance = AccountTypeChat, ChatInstanceFlagClan
+	case "L":
+		id.Type, id.Instance = AccountTypeChat, ChatInstanceFlagLobby
+	case "a":
+		id.Type, id.Instance = AccountTypeAnonUser, InstanceDesktop
+	case "I", "i":
+		id.Type, id.Instance = AccountTypeInvalid, InstanceDesktop
+	default:
+		return fail(errors.New("unknown account type letter " + strconv.Quote(m[1])))
+	}
+
+	universe, err := strconv.ParseUint(m[2], 10, 8)
+	if err != nil {
+		return fail(ErrOutOfRange)
+	}
+	id.Universe = Universe(universe)
+
+	accountID, err := strconv.ParseUint(m[3], 10, 32)
+	if err != nil {
+		return fail(ErrOutOfRange)
+	}
+	id.AccountID = uint32(accountID)
+
+	if m[4] != "" {
+		instance, err := strconv.ParseUint(m[4], 10, 20)
+		if err != nil {
+			return fail(ErrOutOfRange)
+		}
+		id.Instance = uint32(instance)
+	}
+
+	if !id.IsValid() {
+		return fail(ErrInvalidID)
+	}
+	return id, nil
+}
+
+// parseSteamIDNumber parses a bare SteamID32 or SteamID64.
+func parseSteamIDNumber(s string) (ID, error) {
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return ID{}, &ParseError{Input: s, Format: "SteamID64", Err: ErrOutOfRange}
+	}
+
+	if n <= 0xFFFFFFFF {
+		id := SteamID32(n).ID()
+		if !id.IsValid() {
+			return ID{}, &ParseError{Input: s, Format: "SteamID32", Err: ErrInvalidID}
+		}
+		return id, nil
+	}
+
+	id := SteamID64(n).ID()
+	if !id.IsValid() {
+		return ID{}, &ParseError{Input: s, Format: "SteamID64", Err: ErrInvalidID}
+	}
+	return id, nil
+}
@@ -0,0 +1,76 @@
+package steam
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestParseSteamID3Brackets(t *testing.T) {
+	want := ID{Universe: UniversePublic, Type: AccountTypeIndividual, Instance: InstanceDesktop, AccountID: 172346362}
+
+	for _, s := range []string{"[U:1:172346362]", "U:1:172346362", "[U:1:172346362:1]", "U:1:172346362:1"} {
+		id, err := ParseSteamID(s)
+		if err != nil {
+			t.Errorf("ParseSteamID(%q): %v", s, err)
+			continue
+		}
+		if id != want {
+			t.Errorf("ParseSteamID(%q) = %+v, want %+v", s, id, want)
+		}
+	}
+
+	for _, s := range []string{"[U:1:172346362", "U:1:172346362]", "[U:1:172346362:1", "[[U:1:172346362]]"} {
+		if _, err := ParseSteamID(s); !errors.Is(err, ErrUnknownFormat) {
+			t.Errorf("ParseSteamID(%q) error = %v, want ErrUnknownFormat", s, err)
+		}
+	}
+}
+
+func TestIDRoundTrip(t *testing.T) {
+	tests := []struct {
+		id     ID
+		steam3 SteamID3
+		steam2 SteamID // empty if id has no SteamID form
+	}{
+		{ID{UniversePublic, AccountTypeIndividual, InstanceDesktop, 172346362}, "[U:1:172346362]", "STEAM_0:0:86173181"},
+		{ID{UniversePublic, AccountTypeIndividual, InstanceDesktop, 1}, "[U:1:1]", "STEAM_0:1:0"},
+		{ID{UniverseBeta, AccountTypeIndividual, InstanceDesktop, 172346363}, "[U:2:172346363]", "STEAM_2:1:86173181"},
+		{ID{UniversePublic, AccountTypeIndividual, InstanceWeb, 172346362}, "[U:1:172346362:4]", ""},
+		{ID{UniversePublic, AccountTypeClan, InstanceAll, 4777282}, "[g:1:4777282]", ""},
+		{ID{UniverseBeta, AccountTypeClan, InstanceAll, 4777282}, "[g:2:4777282]", ""},
+		{ID{UniversePublic, AccountTypeAnonGameServer, 1234, 5678}, "[A:1:5678:1234]", ""},
+		{ID{UniverseBeta, AccountTypeAnonGameServer, InstanceDesktop, 5678}, "[A:2:5678:1]", ""},
+		{ID{UniversePublic, AccountTypeGameServer, InstanceDesktop, 5678}, "[G:1:5678]", ""},
+		{ID{UniversePublic, AccountTypeGameServer, InstanceAll, 5678}, "[G:1:5678:0]", ""},
+		{ID{UniverseBeta, AccountTypeGameServer, 7, 5678}, "[G:2:5678:7]", ""},
+	}
+	for _, test := range tests {
+		if !test.id.IsValid() {
+			t.Errorf("%+v is not valid", test.id)
+			continue
+		}
+		if got := test.id.SteamID3(); got != test.steam3 {
+			t.Errorf("%+v.SteamID3() = %s, want %s", test.id, got, test.steam3)
+		}
+		forms := []string{string(test.id.SteamID3()), strconv.FormatUint(uint64(test.id.SteamID64()), 10)}
+		if test.steam2 != "" {
+			if got := test.id.SteamID(); got != test.steam2 {
+				t.Errorf("%+v.SteamID() = %s, want %s", test.id, got, test.steam2)
+			}
+			forms = append(forms, string(test.id.SteamID()))
+		}
+
+		for _, s := range forms {
+			id, err := ParseSteamID(s)
+			if err != nil {
+				t.Errorf("ParseSteamID(%q) of %+v: %v", s, test.id, err)
+			} else if id != test.id {
+				t.Errorf("ParseSteamID(%q) = %+v, want %+v", s, id, test.id)
+			}
+		}
+		if id := test.id.SteamID64().ID(); id != test.id {
+			t.Errorf("%+v.SteamID64().ID() = %+v", test.id, id)
+		}
+	}
+}
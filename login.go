@@ -1,9 +1,8 @@
 package steam
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
-	"io/ioutil"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
@@ -12,242 +11,607 @@ import (
 	"time"
 )
 
-// Login logs into steam using the specified username and password and returns a type Account.
+// Login is a wrapper around DefaultClient.Login.
 func Login(username, password string) (*Account, error) {
+	return DefaultClient.Login(username, password)
+}
+
+// LoginContext is a wrapper around DefaultClient.LoginContext.
+func LoginContext(ctx context.Context, username, password string) (*Account, error) {
+	return DefaultClient.LoginContext(ctx, username, password)
+}
+
+// LoginTwoFactor is a wrapper around DefaultClient.LoginTwoFactor.
+func LoginTwoFactor(username, password, sharedSecret string) (*Account, error) {
+	return DefaultClient.LoginTwoFactor(username, password, sharedSecret)
+}
+
+// LoginTwoFactorContext is a wrapper around DefaultClient.LoginTwoFactorContext.
+func LoginTwoFactorContext(ctx context.Context, username, password, sharedSecret string) (*Account, error) {
+	return DefaultClient.LoginTwoFactorContext(ctx, username, password, sharedSecret)
+}
+
+// LoginWithOptions is a wrapper around DefaultClient.LoginWithOptions.
+func LoginWithOptions(username, password string, opts LoginOptions) (*Account, error) {
+	return DefaultClient.LoginWithOptions(username, password, opts)
+}
+
+// LoginWithOptionsContext is a wrapper around DefaultClient.LoginWithOptionsContext.
+func LoginWithOptionsContext(ctx context.Context, username, password string, opts LoginOptions) (*Account, error) {
+	return DefaultClient.LoginWithOptionsContext(ctx, username, password, opts)
+}
+
+// LoginOptions holds the ways of answering the Steam Guard challenges of a
+// login. Relogin answers them the same way as the login which created the
+// Account.
+type LoginOptions struct {
+	// SharedSecret is the base64 shared_secret of the Steam Guard mobile
+	// authenticator of the account, used to generate mobile codes.
+	SharedSecret string
+
+	// TwoFactorCode, if set, is called for a Steam Guard mobile code when
+	// SharedSecret is empty. It is called again if the code is rejected.
+	TwoFactorCode func(ctx context.Context) (string, error)
+
+	// EmailCode, if set, is called for the Steam Guard code emailed to an
+	// address at emailDomain. It is called again if the code is rejected.
+	EmailCode func(ctx context.Context, emailDomain string) (string, error)
+
+	// MachineAuth is the steamMachineAuth cookie of a previous login with an
+	// email code (see Account.MachineAuth). It lets Steam recognise this
+	// machine instead of sending another email code.
+	MachineAuth string
+
+	// CaptchaText, if set, is called with the image of a captcha shown by
+	// Steam and returns the characters of the image. It is called again
+	// with a new image if the answer is rejected.
+	CaptchaText func(ctx context.Context, image []byte) (string, error)
+
+	// IdentitySecret and DeviceID are the identity_secret and device ID of
+	// the mobile authenticator, set on the Account to answer confirmations.
+	IdentitySecret string
+	DeviceID       string
+
+	// OnState, if set, is called as the login enters each LoginState, ending
+	// with LoginStateDone. Returning an error aborts the login with it.
+	OnState func(ctx context.Context, state LoginState) error
+}
+
+// maxCodeAttempts is the number of codes asked of a LoginOptions callback
+// before giving up.
+const maxCodeAttempts = 3
+
+// Login logs into steam using the specified username and password and returns a type Account.
+// The Account uses c for its requests.
+func (c *Client) Login(username, password string) (*Account, error) {
+	return c.LoginContext(context.Background(), username, password)
+}
+
+// LoginContext is like Login but its requests are bound to ctx.
+func (c *Client) LoginContext(ctx context.Context, username, password string) (*Account, error) {
+	return c.LoginWithOptionsContext(ctx, username, password, LoginOptions{})
+}
+
+// LoginTwoFactor is like Login for an account protected by the Steam Guard
+// mobile authenticator. The codes are generated from the base64 shared_secret
+// of the authenticator.
+func (c *Client) LoginTwoFactor(username, password, sharedSecret string) (*Account, error) {
+	return c.LoginTwoFactorContext(context.Background(), username, password, sharedSecret)
+}
+
+// LoginTwoFactorContext is like LoginTwoFactor but its requests are bound to ctx.
+func (c *Client) LoginTwoFactorContext(ctx context.Context, username, password, sharedSecret string) (*Account, error) {
+	return c.LoginWithOptionsContext(ctx, username, password, LoginOptions{SharedSecret: sharedSecret})
+}
+
+// LoginWithOptions is like Login but answers Steam Guard challenges as set in
+// opts. The errors ErrInvalidCredentials, ErrTwoFactorRequired,
+// ErrTwoFactorRejected, ErrEmailAuthRequired, ErrEmailAuthRejected,
+// ErrCaptchaRequired and ErrCaptchaRejected tell which step failed.
+func (c *Client) LoginWithOptions(username, password string, opts LoginOptions) (*Account, error) {
+	return c.LoginWithOptionsContext(context.Background(), username, password, opts)
+}
+
+// LoginWithOptionsContext is like LoginWithOptions but its requests are bound to ctx.
+func (c *Client) LoginWithOptionsContext(ctx context.Context, username, password string, opts LoginOptions) (*Account, error) {
 	acc := Account{
-		Username: username,
-		Password: password,
+		Username:       username,
+		Password:       password,
+		SharedSecret:   opts.SharedSecret,
+		IdentitySecret: opts.IdentitySecret,
+		DeviceID:       opts.DeviceID,
+		Client:         c,
+		loginOptions:   opts,
 	}
 	cookieJar, _ := cookiejar.New(nil)
-	acc.HttpClient = &http.Client{Jar: cookieJar, Timeout: time.Duration(120 * time.Second)}
+	acc.HttpClient = &http.Client{Jar: cookieJar, Timeout: time.Duration(120 * time.Second), Transport: c.httpClient().Transport}
 
-	resp, err := acc.HttpClient.PostForm("https://steamcommunity.com/login/getrsakey", url.Values{
-		"donotcache": {strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)},
-		"username":   {acc.Username},
-	})
-	if err != nil {
-		return &acc, err
-	}
-	defer resp.Body.Close()
+	return &acc, acc.login(ctx)
+}
 
-	content, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return &acc, err
+// rsaKey is the key returned by getrsakey to encrypt the password with.
+type rsaKey struct {
+	Success       bool
+	Publickey_mod string
+	Publickey_exp string
+	Timestamp     string
+	Token_gid     string
+}
+
+// loginResult is the response of dologin.
+type loginResult struct {
+	Success             bool
+	Requires_twofactor  bool
+	Emailauth_needed    bool
+	Emaildomain         string
+	Emailsteamid        string
+	Captcha_needed      bool
+	Captcha_gid         captchaGID
+	Login_complete      bool
+	Transfer_urls       []string
+	Transfer_parameters struct {
+		SteamId        string
+		Token          string
+		Auth           string
+		Remember_login bool
+		Token_secure   string
+		Webcookie      string
 	}
+	Message string
+}
 
-	var rsakeyResult struct {
-		Success       bool
-		Publickey_mod string
-		Publickey_exp string
-		Timestamp     string
-		Token_gid     string
+// LoginState is a step of the login flow. A login starts with
+// LoginStateRSAKey and moves through the states below until LoginStateDone
+// or an error.
+type LoginState int
+
+const (
+	LoginStateRSAKey      LoginState = iota // fetching the key to encrypt the password with
+	LoginStateCredentials                   // posting the credentials and answers to dologin
+	LoginStateTwoFactor                     // getting a Steam Guard mobile code
+	LoginStateEmailAuth                     // getting a Steam Guard email code
+	LoginStateCaptcha                       // solving a captcha
+	LoginStateTransfer                      // posting the transfer parameters to set the session cookies
+	LoginStateDone                          // logged in
+)
+
+// String returns the name of s.
+func (s LoginState) String() string {
+	switch s {
+	case LoginStateRSAKey:
+		return "RSAKey"
+	case LoginStateCredentials:
+		return "Credentials"
+	case LoginStateTwoFactor:
+		return "TwoFactor"
+	case LoginStateEmailAuth:
+		return "EmailAuth"
+	case LoginStateCaptcha:
+		return "Captcha"
+	case LoginStateTransfer:
+		return "Transfer"
+	case LoginStateDone:
+		return "Done"
 	}
-	if err := json.Unmarshal(content, &rsakeyResult); err != nil {
-		if err.Error() == "invalid character '<' looking for beginning of value" {
-			return &acc, jsonUnmarshallErrorCheck(content)
+	return "LoginState(" + strconv.Itoa(int(s)) + ")"
+}
+
+// LoginTransfer holds the session credentials handed out by a successful
+// login.
+type LoginTransfer struct {
+	SteamID     SteamID64
+	Token       string
+	TokenSecure string
+	Auth        string
+	WebCookie   string
+	URLs        []string // the transfer URLs the parameters were posted to
+
+	// SteamLoginSecure is the steamLoginSecure cookie set by the transfer,
+	// which authenticates the session on the community website.
+	SteamLoginSecure string
+}
+
+// LoginTransfer returns the session credentials of the last login of acc, or
+// nil if it never completed a login.
+func (acc *Account) LoginTransfer() *LoginTransfer {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	return acc.lastTransfer
+}
+
+// loginFlow is a login in progress.
+type loginFlow struct {
+	acc    *Account
+	opts   LoginOptions
+	form   url.Values   // the form posted to dologin
+	result *loginResult // the last response of dologin
+
+	captchaGID                                        string
+	twoFactorAttempts, emailAttempts, captchaAttempts int
+	machineAuthSent                                   bool
+}
+
+// login logs acc in with its username and password, answering the Steam
+// Guard challenges with its SharedSecret and login options.
+func (acc *Account) login(ctx context.Context) error {
+	f := &loginFlow{acc: acc, opts: acc.loginOptions}
+
+	state := LoginStateRSAKey
+	for {
+		if f.opts.OnState != nil {
+			if err := f.opts.OnState(ctx, state); err != nil {
+				return err
+			}
+		}
+		if state == LoginStateDone {
+			return nil
 		}
-		return &acc, err
-	}
 
-	if rsakeyResult.Success != true {
-		return &acc, errors.New("failed to retrieve RSA key")
+		var err error
+		if state, err = f.step(ctx, state); err != nil {
+			return err
+		}
 	}
+}
 
-	encryptedPassword := encryptPassword(acc.Password, rsakeyResult.Publickey_mod, rsakeyResult.Publickey_exp)
-	if encryptedPassword == "" {
-		return &acc, errors.New("unable to encrypt password")
+// step runs state and returns the state which follows it.
+func (f *loginFlow) step(ctx context.Context, state LoginState) (LoginState, error) {
+	switch state {
+	case LoginStateRSAKey:
+		return f.rsaKey(ctx)
+	case LoginStateCredentials:
+		return f.credentials(ctx)
+	case LoginStateTwoFactor:
+		return f.twoFactor(ctx)
+	case LoginStateEmailAuth:
+		return f.emailAuth(ctx)
+	case LoginStateCaptcha:
+		return f.captcha(ctx)
+	case LoginStateTransfer:
+		return f.transfer(ctx)
 	}
+	return state, errors.New("invalid login state " + state.String())
+}
 
-	resp, err = acc.HttpClient.PostForm("https://steamcommunity.com/login/dologin", url.Values{
-		"donotcache":   {strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)},
-		"username":     {acc.Username},
-		"password":     {encryptedPassword},
-		"rsatimestamp": {rsakeyResult.Timestamp},
-		"captchagid":   {"-1"},
-		"captcha_text": {""},
-	})
+// rsaKey encrypts the password with the key of the account.
+func (f *loginFlow) rsaKey(ctx context.Context) (LoginState, error) {
+	key, err := f.acc.getRSAKey(ctx)
 	if err != nil {
-		return &acc, err
+		return LoginStateRSAKey, err
 	}
-	defer resp.Body.Close()
 
-	content, err = ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return &acc, err
-	}
-
-	var loginResult struct {
-		Success             bool
-		Requires_twofactor  bool
-		Login_complete      bool
-		Transfer_urls       []string
-		Transfer_parameters struct {
-			SteamId        string
-			Token          string
-			Auth           string
-			Remember_login bool
-			Token_secure   string
-		}
-		Message string
-	}
-	if err = json.Unmarshal(content, &loginResult); err != nil {
-		if err.Error() == "invalid character '<' looking for beginning of value" {
-			return &acc, jsonUnmarshallErrorCheck(content)
-		}
-		return &acc, err
+	encryptedPassword := encryptPassword(f.acc.Password, key.Publickey_mod, key.Publickey_exp)
+	if encryptedPassword == "" {
+		return LoginStateRSAKey, errors.New("unable to encrypt password")
 	}
 
-	SteamId, err := strconv.Atoi(loginResult.Transfer_parameters.SteamId)
-	if err == nil {
-		acc.SteamID = SteamID64(SteamId)
+	f.form = url.Values{
+		"username":      {f.acc.Username},
+		"password":      {encryptedPassword},
+		"rsatimestamp":  {key.Timestamp},
+		"twofactorcode": {""},
+		"emailauth":     {""},
+		"emailsteamid":  {""},
+		"captchagid":    {"-1"},
+		"captcha_text":  {""},
 	}
+	return LoginStateCredentials, nil
+}
 
-	if loginResult.Success != true || loginResult.Login_complete != true {
-		return &acc, errors.New("failed to login: " + loginResult.Message)
+// credentials posts the form to dologin and picks the next state from the
+// challenge in the response.
+func (f *loginFlow) credentials(ctx context.Context) (LoginState, error) {
+	result, err := f.acc.doLogin(ctx, f.form)
+	if err != nil {
+		return LoginStateCredentials, err
 	}
+	f.result = result
 
-	for _, transferUrl := range loginResult.Transfer_urls {
-		resp, err = acc.HttpClient.PostForm(transferUrl, url.Values{
-			"steamid":        {loginResult.Transfer_parameters.SteamId},
-			"token":          {loginResult.Transfer_parameters.Token},
-			"auth":           {loginResult.Transfer_parameters.Auth},
-			"token_secure":   {loginResult.Transfer_parameters.Token_secure},
-			"remember_login": {"true"},
-		})
-		if err != nil {
-			return &acc, err
-		}
-		resp.Body.Close()
-	}
+	switch {
+	case result.Captcha_needed:
+		f.captchaGID = string(result.Captcha_gid)
+		return LoginStateCaptcha, nil
 
-	return &acc, nil
-}
+	case result.Requires_twofactor:
+		return LoginStateTwoFactor, nil
 
-// Logout logs out of Steam for a specified Account clearning all existing cookies.
-func (acc *Account) Logout() {
-	sessionID, _ := acc.getSessionId()
-	acc.HttpClient.PostForm("https://steamcommunity.com/login/logout/", url.Values{
-		"sessionid": {sessionID},
-	})
-	cookieJar, _ := cookiejar.New(nil)
-	acc.HttpClient.Jar = cookieJar
+	case result.Emailauth_needed:
+		if f.opts.MachineAuth != "" && !f.machineAuthSent && result.Emailsteamid != "" {
+			f.acc.setMachineAuth(result.Emailsteamid, f.opts.MachineAuth)
+			f.machineAuthSent = true
+			return LoginStateCredentials, nil
+		}
+		return LoginStateEmailAuth, nil
+
+	case result.Success != true || result.Login_complete != true:
+		return LoginStateCredentials, loginError(result.Message)
+	}
+	return LoginStateTransfer, nil
 }
 
-// IsLoggedIn returns a bool based on weather an Account is logged in or not.
-func (acc *Account) IsLoggedIn() bool {
-	resp, err := acc.HttpClient.Get("http://steamcommunity.com/")
+// twoFactor answers a Steam Guard mobile challenge.
+func (f *loginFlow) twoFactor(ctx context.Context) (LoginState, error) {
+	code, err := f.acc.twoFactorCode(ctx, f.twoFactorAttempts)
 	if err != nil {
-		return false
+		return LoginStateTwoFactor, err
 	}
+	f.twoFactorAttempts++
+	f.form.Set("twofactorcode", code)
+	return LoginStateCredentials, nil
+}
 
-	content, err := ioutil.ReadAll(resp.Body)
+// emailAuth answers a Steam Guard email challenge.
+func (f *loginFlow) emailAuth(ctx context.Context) (LoginState, error) {
+	if f.opts.EmailCode == nil {
+		if f.emailAttempts > 0 {
+			return LoginStateEmailAuth, ErrEmailAuthRejected
+		}
+		return LoginStateEmailAuth, ErrEmailAuthRequired
+	}
+	if f.emailAttempts >= maxCodeAttempts {
+		return LoginStateEmailAuth, ErrEmailAuthRejected
+	}
+	code, err := f.opts.EmailCode(ctx, f.result.Emaildomain)
 	if err != nil {
-		return false
+		return LoginStateEmailAuth, err
 	}
-
-	return !strings.Contains(string(content), "https://steamcommunity.com/login/home")
+	f.emailAttempts++
+	f.form.Set("emailauth", code)
+	f.form.Set("emailsteamid", f.result.Emailsteamid)
+	return LoginStateCredentials, nil
 }
 
-// Relogin logs into Steam again from a previous type Account updating the session.
-func (acc *Account) Relogin() error {
-	resp, err := acc.HttpClient.PostForm("https://steamcommunity.com/login/getrsakey", url.Values{
-		"donotcache": {strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)},
-		"username":   {acc.Username},
-	})
+// captcha answers a captcha challenge.
+func (f *loginFlow) captcha(ctx context.Context) (LoginState, error) {
+	if f.opts.CaptchaText == nil {
+		if f.captchaAttempts > 0 {
+			return LoginStateCaptcha, ErrCaptchaRejected
+		}
+		return LoginStateCaptcha, ErrCaptchaRequired
+	}
+	if f.captchaAttempts >= maxCodeAttempts {
+		return LoginStateCaptcha, ErrCaptchaRejected
+	}
+	gid, text, err := f.acc.solveCaptcha(ctx, f.captchaGID)
 	if err != nil {
-		return err
+		return LoginStateCaptcha, err
 	}
-	defer resp.Body.Close()
+	f.captchaAttempts++
+	f.form.Set("captchagid", gid)
+	f.form.Set("captcha_text", text)
+	return LoginStateCredentials, nil
+}
 
-	content, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
+// transfer sets the session cookies of the successful login.
+func (f *loginFlow) transfer(ctx context.Context) (LoginState, error) {
+	if err := f.acc.transfer(ctx, f.result); err != nil {
+		return LoginStateTransfer, err
 	}
+	return LoginStateDone, nil
+}
 
-	var rsakeyResult struct {
-		Success       bool
-		Publickey_mod string
-		Publickey_exp string
-		Timestamp     string
-		Token_gid     string
+// twoFactorCode returns the Steam Guard mobile code to answer a challenge
+// with, attempts being the number of codes already rejected.
+func (acc *Account) twoFactorCode(ctx context.Context, attempts int) (string, error) {
+	if acc.SharedSecret != "" {
+		// Generated codes are only retried once, in case the previous one
+		// expired on its way to Steam.
+		if attempts >= 2 {
+			return "", ErrTwoFactorRejected
+		}
+		return GenerateAuthCode(acc.SharedSecret, acc.client().ServerTimeContext(ctx))
 	}
-	if err := json.Unmarshal(content, &rsakeyResult); err != nil {
-		if err.Error() == "invalid character '<' looking for beginning of value" {
-			return jsonUnmarshallErrorCheck(content)
+
+	callback := acc.loginOptions.TwoFactorCode
+	if callback == nil {
+		if attempts > 0 {
+			return "", ErrTwoFactorRejected
 		}
-		return err
+		return "", ErrTwoFactorRequired
 	}
+	if attempts >= maxCodeAttempts {
+		return "", ErrTwoFactorRejected
+	}
+	return callback(ctx)
+}
 
-	if rsakeyResult.Success != true {
-		return errors.New("failed to retrieve RSA key")
+// loginError returns the error for a failed login with message.
+func loginError(message string) error {
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "incorrect"):
+		return ErrInvalidCredentials
+	case strings.Contains(lower, "too many"):
+		return ErrRateLimited
 	}
+	return errors.New("failed to login: " + message)
+}
 
-	encryptedPassword := encryptPassword(acc.Password, rsakeyResult.Publickey_mod, rsakeyResult.Publickey_exp)
-	if encryptedPassword == "" {
-		return errors.New("unable to encrypt password")
+// machineAuthCookie is the prefix of the name of the cookie which remembers
+// a machine that answered a Steam Guard email code. The SteamID follows.
+const machineAuthCookie = "steamMachineAuth"
+
+// setMachineAuth sets the steamMachineAuth cookie of steamID to value.
+func (acc *Account) setMachineAuth(steamID, value string) {
+	u, err := url.Parse(acc.client().communityURL("", nil))
+	httpClient := acc.httpClient()
+	if err != nil || httpClient == nil || httpClient.Jar == nil {
+		return
 	}
+	httpClient.Jar.SetCookies(u, []*http.Cookie{{
+		Name:  machineAuthCookie + steamID,
+		Value: value,
+		Path:  "/",
+	}})
+}
 
-	resp, err = acc.HttpClient.PostForm("https://steamcommunity.com/login/dologin", url.Values{
-		"donotcache":   {strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)},
-		"username":     {acc.Username},
-		"password":     {encryptedPassword},
-		"rsatimestamp": {rsakeyResult.Timestamp},
-		"captchagid":   {"-1"},
-		"captcha_text": {""},
+// MachineAuth returns the steamMachineAuth cookie set by Steam after a login
+// with an email code, to be passed in LoginOptions.MachineAuth next time.
+// An empty string is returned if there is none.
+func (acc *Account) MachineAuth() string {
+	u, err := url.Parse(acc.client().communityURL("", nil))
+	httpClient := acc.httpClient()
+	if err != nil || httpClient == nil || httpClient.Jar == nil {
+		return ""
+	}
+	for _, cookie := range httpClient.Jar.Cookies(u) {
+		if strings.HasPrefix(cookie.Name, machineAuthCookie) {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// getRSAKey returns the key to encrypt the password of acc with.
+func (acc *Account) getRSAKey(ctx context.Context) (*rsaKey, error) {
+	content, err := acc.postForm(ctx, acc.client().communityURL("login/getrsakey", nil), url.Values{
+		"donotcache": {strconv.FormatInt(makeTimestamp(), 10)},
+		"username":   {acc.Username},
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	content, err = ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
+	var key rsaKey
+	if err := unmarshalJSON(content, &key); err != nil {
+		return nil, err
 	}
 
-	var loginResult struct {
-		Success             bool
-		Requires_twofactor  bool
-		Login_complete      bool
-		Transfer_urls       []string
-		Transfer_parameters struct {
-			SteamId        string
-			Token          string
-			Auth           string
-			Remember_login bool
-			Token_secure   string
-		}
-		Message string
+	if key.Success != true {
+		return nil, errors.New("failed to retrieve RSA key")
 	}
-	if err = json.Unmarshal(content, &loginResult); err != nil {
-		if err.Error() == "invalid character '<' looking for beginning of value" {
-			return jsonUnmarshallErrorCheck(content)
-		}
-		return err
+	return &key, nil
+}
+
+// doLogin posts form to dologin and returns the result. The SteamID of acc is
+// set as soon as Steam tells it.
+func (acc *Account) doLogin(ctx context.Context, form url.Values) (*loginResult, error) {
+	form.Set("donotcache", strconv.FormatInt(makeTimestamp(), 10))
+
+	content, err := acc.postForm(ctx, acc.client().communityURL("login/dologin", nil), form)
+	if err != nil {
+		return nil, err
 	}
 
-	SteamId, err := strconv.Atoi(loginResult.Transfer_parameters.SteamId)
-	if err == nil {
-		acc.SteamID = SteamID64(SteamId)
+	var result loginResult
+	if err = unmarshalJSON(content, &result); err != nil {
+		return nil, err
 	}
 
-	if loginResult.Success != true || loginResult.Login_complete != true {
-		return errors.New("failed to login: " + loginResult.Message)
+	SteamId, err := strconv.ParseUint(result.Transfer_parameters.SteamId, 10, 64)
+	if err == nil {
+		acc.setSteamID(SteamID64(SteamId))
 	}
+	return &result, nil
+}
 
-	for _, transferUrl := range loginResult.Transfer_urls {
-		resp, err = acc.HttpClient.PostForm(transferUrl, url.Values{
-			"steamid":        {loginResult.Transfer_parameters.SteamId},
-			"token":          {loginResult.Transfer_parameters.Token},
-			"auth":           {loginResult.Transfer_parameters.Auth},
-			"token_secure":   {loginResult.Transfer_parameters.Token_secure},
+// transfer posts the transfer parameters of a successful login to every
+// transfer URL, which sets the session cookies, and keeps them as the
+// LoginTransfer of acc.
+func (acc *Account) transfer(ctx context.Context, result *loginResult) error {
+	params := result.Transfer_parameters
+	for _, transferUrl := range result.Transfer_urls {
+		_, err := acc.postForm(ctx, transferUrl, url.Values{
+			"steamid":        {params.SteamId},
+			"token":          {params.Token},
+			"auth":           {params.Auth},
+			"token_secure":   {params.Token_secure},
+			"webcookie":      {params.Webcookie},
 			"remember_login": {"true"},
 		})
 		if err != nil {
 			return err
 		}
-		resp.Body.Close()
 	}
 
+	steamID, _ := strconv.ParseUint(params.SteamId, 10, 64)
+	transfer := &LoginTransfer{
+		SteamID:          SteamID64(steamID),
+		Token:            params.Token,
+		TokenSecure:      params.Token_secure,
+		Auth:             params.Auth,
+		WebCookie:        params.Webcookie,
+		URLs:             result.Transfer_urls,
+		SteamLoginSecure: acc.cookie("steamLoginSecure"),
+	}
+	acc.mu.Lock()
+	acc.lastTransfer = transfer
+	acc.mu.Unlock()
+	return nil
+}
+
+// cookie returns the value of the community cookie name of acc, or an empty
+// string if it is not set.
+func (acc *Account) cookie(name string) string {
+	u, err := url.Parse(acc.client().communityURL("", nil))
+	httpClient := acc.httpClient()
+	if err != nil || httpClient == nil || httpClient.Jar == nil {
+		return ""
+	}
+	for _, cookie := range httpClient.Jar.Cookies(u) {
+		if cookie.Name == name {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// Logout logs out of Steam for a specified Account clearning all existing cookies.
+func (acc *Account) Logout() {
+	acc.LogoutContext(context.Background())
+}
+
+// LogoutContext is like Logout but its requests are bound to ctx.
+func (acc *Account) LogoutContext(ctx context.Context) {
+	sessionID, _ := acc.getSessionId(ctx)
+	acc.postForm(ctx, acc.client().communityURL("login/logout/", nil), url.Values{
+		"sessionid": {sessionID},
+	})
+	// The client is replaced rather than changed, as requests in progress
+	// are using it.
+	cookieJar, _ := cookiejar.New(nil)
+	acc.mu.Lock()
+	httpClient := http.Client{}
+	if acc.HttpClient != nil {
+		httpClient = *acc.HttpClient
+	}
+	httpClient.Jar = cookieJar
+	acc.HttpClient = &httpClient
+	acc.mu.Unlock()
+	acc.resetChat()
+}
+
+// IsLoggedIn returns a bool based on weather an Account is logged in or not.
+func (acc *Account) IsLoggedIn() bool {
+	return acc.IsLoggedInContext(context.Background())
+}
+
+// IsLoggedInContext is like IsLoggedIn but its requests are bound to ctx.
+func (acc *Account) IsLoggedInContext(ctx context.Context) bool {
+	return acc.checkSession(ctx) == nil
+}
+
+// checkSession returns ErrSessionExpired if Steam serves the login page to
+// acc, or the error of the request if it fails.
+func (acc *Account) checkSession(ctx context.Context) error {
+	content, err := acc.get(ctx, acc.client().communityURL("", nil))
+	if err != nil {
+		return err
+	}
+	if strings.Contains(string(content), acc.client().communityURL("login/home", nil)) {
+		return ErrSessionExpired
+	}
 	return nil
 }
+
+// Relogin logs into Steam again from a previous type Account updating the session.
+func (acc *Account) Relogin() error {
+	return acc.ReloginContext(context.Background())
+}
+
+// ReloginContext is like Relogin but its requests are bound to ctx.
+func (acc *Account) ReloginContext(ctx context.Context) error {
+	acc.relogin.mu.Lock()
+	defer acc.relogin.mu.Unlock()
+	return acc.loginAgain(ctx)
+}
@@ -0,0 +1,232 @@
+package steam_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Acidic9/steam"
+	"github.com/Acidic9/steam/steamtest"
+)
+
+// sharedSecret is the shared_secret of the mobile authenticator of the users
+// which have one.
+const sharedSecret = "c2VjcmV0LXNoYXJlZC1zZWNyZXQ="
+
+// answers returns a callback answering a login challenge with texts in turn,
+// and then with the last of them.
+func answers(texts ...string) func() (string, error) {
+	i := 0
+	return func() (string, error) {
+		text := texts[i]
+		if i < len(texts)-1 {
+			i++
+		}
+		return text, nil
+	}
+}
+
+func TestLoginStates(t *testing.T) {
+	const (
+		rsa       = steam.LoginStateRSAKey
+		cred      = steam.LoginStateCredentials
+		twoFactor = steam.LoginStateTwoFactor
+		email     = steam.LoginStateEmailAuth
+		captcha   = steam.LoginStateCaptcha
+		transfer  = steam.LoginStateTransfer
+		done      = steam.LoginStateDone
+	)
+	errAbort := errors.New("aborted")
+	mobileCode := func() (string, error) {
+		return steam.GenerateAuthCode(sharedSecret, time.Now())
+	}
+
+	tests := []struct {
+		name     string
+		user     steamtest.User // the password is "hunter2"
+		password string         // the password logged in with, if not "hunter2"
+		captcha  string         // the answer of the captchas required, if any
+		fault    *steamtest.Fault
+
+		sharedSecret string
+		twoFactor    func() (string, error)
+		emailCode    func() (string, error)
+		captchaText  func() (string, error)
+		abortAt      *steam.LoginState
+
+		states []steam.LoginState
+		err    error
+	}{
+		{name: "password",
+			states: []steam.LoginState{rsa, cred, transfer, done}},
+		{name: "wrong password", password: "hunter3",
+			states: []steam.LoginState{rsa, cred},
+			err:    steam.ErrInvalidCredentials},
+		{name: "too many failures",
+			fault: &steamtest.Fault{Path: "/login/dologin", StatusCode: http.StatusOK,
+				Body: `{"success":false,"message":"There have been too many login failures from your network in a short time period.  Please wait and try again later.","captcha_needed":false,"captcha_gid":-1}`},
+			states: []steam.LoginState{rsa, cred},
+			err:    steam.ErrRateLimited},
+
+		{name: "shared secret", user: steamtest.User{SharedSecret: sharedSecret}, sharedSecret: sharedSecret,
+			states: []steam.LoginState{rsa, cred, twoFactor, cred, transfer, done}},
+		{name: "wrong shared secret", user: steamtest.User{SharedSecret: sharedSecret}, sharedSecret: "b3RoZXItc2hhcmVkLXNlY3JldA==",
+			states: []steam.LoginState{rsa, cred, twoFactor, cred, twoFactor, cred, twoFactor},
+			err:    steam.ErrTwoFactorRejected},
+		{name: "mobile code", user: steamtest.User{SharedSecret: sharedSecret}, twoFactor: mobileCode,
+			states: []steam.LoginState{rsa, cred, twoFactor, cred, transfer, done}},
+		{name: "mobile code retried", user: steamtest.User{SharedSecret: sharedSecret},
+			twoFactor: func() func() (string, error) {
+				wrong := true
+				return func() (string, error) {
+					if wrong {
+						wrong = false
+						return "XXXXX", nil
+					}
+					return mobileCode()
+				}
+			}(),
+			states: []steam.LoginState{rsa, cred, twoFactor, cred, twoFactor, cred, transfer, done}},
+		{name: "mobile code rejected", user: steamtest.User{SharedSecret: sharedSecret}, twoFactor: answers("XXXXX"),
+			states: []steam.LoginState{rsa, cred, twoFactor, cred, twoFactor, cred, twoFactor, cred, twoFactor},
+			err:    steam.ErrTwoFactorRejected},
+		{name: "mobile code required", user: steamtest.User{SharedSecret: sharedSecret},
+			states: []steam.LoginState{rsa, cred, twoFactor},
+			err:    steam.ErrTwoFactorRequired},
+
+		{name: "email code", user: steamtest.User{EmailCode: "F00D5"}, emailCode: answers("F00D5"),
+			states: []steam.LoginState{rsa, cred, email, cred, transfer, done}},
+		{name: "email code retried", user: steamtest.User{EmailCode: "F00D5"}, emailCode: answers("BAD00", "F00D5"),
+			states: []steam.LoginState{rsa, cred, email, cred, email, cred, transfer, done}},
+		{name: "email code rejected", user: steamtest.User{EmailCode: "F00D5"}, emailCode: answers("BAD00"),
+			states: []steam.LoginState{rsa, cred, email, cred, email, cred, email, cred, email},
+			err:    steam.ErrEmailAuthRejected},
+		{name: "email code required", user: steamtest.User{EmailCode: "F00D5"},
+			states: []steam.LoginState{rsa, cred, email},
+			err:    steam.ErrEmailAuthRequired},
+
+		{name: "captcha", captcha: "h4x0r", captchaText: answers("h4x0r"),
+			states: []steam.LoginState{rsa, cred, captcha, cred, transfer, done}},
+		{name: "captcha retried", captcha: "h4x0r", captchaText: answers("wrong", "h4x0r"),
+			states: []steam.LoginState{rsa, cred, captcha, cred, captcha, cred, transfer, done}},
+		{name: "captcha rejected", captcha: "h4x0r", captchaText: answers("wrong"),
+			states: []steam.LoginState{rsa, cred, captcha, cred, captcha, cred, captcha, cred, captcha},
+			err:    steam.ErrCaptchaRejected},
+		{name: "captcha required", captcha: "h4x0r",
+			states: []steam.LoginState{rsa, cred, captcha},
+			err:    steam.ErrCaptchaRequired},
+		{name: "captcha and mobile code", user: steamtest.User{SharedSecret: sharedSecret}, sharedSecret: sharedSecret,
+			captcha: "h4x0r", captchaText: answers("h4x0r"),
+			// Each post to dologin needs a new captcha.
+			states: []steam.LoginState{rsa, cred, captcha, cred, twoFactor, cred, captcha, cred, transfer, done}},
+
+		{name: "aborted by OnState", user: steamtest.User{SharedSecret: sharedSecret}, sharedSecret: sharedSecret,
+			abortAt: func() *steam.LoginState { s := twoFactor; return &s }(),
+			states:  []steam.LoginState{rsa, cred, twoFactor},
+			err:     errAbort},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := steamtest.New()
+			defer srv.Close()
+			user := test.user
+			user.Username, user.Password, user.SteamID = "bot", "hunter2", botID
+			srv.AddUser(user)
+			srv.RequireCaptcha(test.captcha)
+			if test.fault != nil {
+				srv.Inject(*test.fault)
+			}
+
+			var states []steam.LoginState
+			opts := steam.LoginOptions{
+				SharedSecret: test.sharedSecret,
+				OnState: func(ctx context.Context, state steam.LoginState) error {
+					states = append(states, state)
+					if test.abortAt != nil && state == *test.abortAt {
+						return errAbort
+					}
+					return nil
+				},
+			}
+			if test.twoFactor != nil {
+				opts.TwoFactorCode = func(ctx context.Context) (string, error) {
+					return test.twoFactor()
+				}
+			}
+			if test.emailCode != nil {
+				opts.EmailCode = func(ctx context.Context, emailDomain string) (string, error) {
+					if emailDomain == "" {
+						t.Error("EmailCode called without the email domain")
+					}
+					return test.emailCode()
+				}
+			}
+			if test.captchaText != nil {
+				opts.CaptchaText = func(ctx context.Context, image []byte) (string, error) {
+					if len(image) == 0 {
+						t.Error("CaptchaText called without an image")
+					}
+					return test.captchaText()
+				}
+			}
+
+			password := test.password
+			if password == "" {
+				password = "hunter2"
+			}
+			acc, err := srv.Client().LoginWithOptions("bot", password, opts)
+			if !errors.Is(err, test.err) {
+				t.Errorf("error = %v, want %v", err, test.err)
+			}
+			if !reflect.DeepEqual(states, test.states) {
+				t.Errorf("states = %v, want %v", states, test.states)
+			}
+			if err == nil && (acc.SteamID != botID || acc.LoginTransfer() == nil) {
+				t.Errorf("logged in as %v with the transfer %+v", acc.SteamID, acc.LoginTransfer())
+			}
+		})
+	}
+}
+
+func TestLoginMachineAuth(t *testing.T) {
+	srv := steamtest.New()
+	defer srv.Close()
+	srv.AddUser(steamtest.User{Username: "bot", Password: "hunter2", SteamID: botID, EmailCode: "F00D5"})
+	client := srv.Client()
+
+	acc, err := client.LoginWithOptions("bot", "hunter2", steam.LoginOptions{
+		EmailCode: func(ctx context.Context, emailDomain string) (string, error) {
+			return "F00D5", nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	machineAuth := acc.MachineAuth()
+	if machineAuth == "" {
+		t.Fatal("no MachineAuth after a login with an email code")
+	}
+
+	// The machine is recognised, so no email code is asked.
+	var states []steam.LoginState
+	if _, err := client.LoginWithOptions("bot", "hunter2", steam.LoginOptions{
+		MachineAuth: machineAuth,
+		OnState: func(ctx context.Context, state steam.LoginState) error {
+			states = append(states, state)
+			return nil
+		},
+	}); err != nil {
+		t.Fatalf("login with the MachineAuth of the previous one: %v", err)
+	}
+	want := []steam.LoginState{steam.LoginStateRSAKey, steam.LoginStateCredentials, steam.LoginStateCredentials, steam.LoginStateTransfer, steam.LoginStateDone}
+	if !reflect.DeepEqual(states, want) {
+		t.Errorf("states = %v, want %v", states, want)
+	}
+
+	if _, err := client.LoginWithOptions("bot", "hunter2", steam.LoginOptions{MachineAuth: "0123456789"}); !errors.Is(err, steam.ErrEmailAuthRequired) {
+		t.Errorf("login with an unknown MachineAuth: %v, want ErrEmailAuthRequired", err)
+	}
+}
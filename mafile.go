@@ -0,0 +1,222 @@
+package steam
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Errors returned when loading an encrypted maFile.
+var (
+	// ErrMaFileEncrypted is returned for an encrypted maFile loaded without
+	// a passkey.
+	ErrMaFileEncrypted = errors.New("steam: maFile is encrypted")
+
+	// ErrMaFilePasskey is returned when an encrypted maFile cannot be
+	// decrypted with the given passkey.
+	ErrMaFilePasskey = errors.New("steam: wrong maFile passkey")
+)
+
+// The parameters Steam Desktop Authenticator derives encryption keys with.
+const (
+	maFileKeyIterations = 50000
+	maFileKeySize       = 32
+)
+
+// MaFile holds the credentials of a Steam Guard mobile authenticator, as
+// stored by Steam Desktop Authenticator in a .maFile.
+type MaFile struct {
+	AccountName    string         `json:"account_name"`
+	SharedSecret   string         `json:"shared_secret"`
+	IdentitySecret string         `json:"identity_secret"`
+	DeviceID       string         `json:"device_id"`
+	SerialNumber   string         `json:"serial_number"`
+	RevocationCode string         `json:"revocation_code"`
+	Session        *MaFileSession `json:"Session"`
+}
+
+// MaFileSession is the session saved in a maFile by Steam Desktop
+// Authenticator.
+type MaFileSession struct {
+	SteamID          SteamID64 `json:"SteamID"`
+	SessionID        string    `json:"SessionID"`
+	SteamLogin       string    `json:"SteamLogin"`
+	SteamLoginSecure string    `json:"SteamLoginSecure"`
+	WebCookie        string    `json:"WebCookie"`
+	OAuthToken       string    `json:"OAuthToken"`
+}
+
+// SteamID returns the SteamID of the account of m, or 0 if m has no session.
+func (m *MaFile) SteamID() SteamID64 {
+	if m.Session == nil {
+		return 0
+	}
+	return m.Session.SteamID
+}
+
+// LoginOptions returns the options to log in with the authenticator of m.
+// The Account logged in can answer two-factor challenges and confirmations.
+func (m *MaFile) LoginOptions() LoginOptions {
+	return LoginOptions{
+		SharedSecret:   m.SharedSecret,
+		IdentitySecret: m.IdentitySecret,
+		DeviceID:       m.DeviceID,
+	}
+}
+
+// ParseMaFile parses the content of an unencrypted maFile.
+func ParseMaFile(data []byte) (*MaFile, error) {
+	var m MaFile
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	if m.SharedSecret == "" && m.IdentitySecret == "" {
+		return nil, errors.New("steam: maFile has no secrets")
+	}
+	return &m, nil
+}
+
+// DecryptMaFile decrypts and parses the content of a maFile encrypted with
+// passkey. salt and iv are the base64 encryption_salt and encryption_iv of
+// the file's entry in the manifest.json of Steam Desktop Authenticator.
+func DecryptMaFile(data []byte, passkey, salt, iv string) (*MaFile, error) {
+	saltBytes, err := base64.StdEncoding.DecodeString(salt)
+	if err != nil {
+		return nil, err
+	}
+	ivBytes, err := base64.StdEncoding.DecodeString(iv)
+	if err != nil {
+		return nil, err
+	}
+	if len(ivBytes) != aes.BlockSize {
+		return nil, errors.New("steam: invalid maFile iv")
+	}
+	encrypted, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted) == 0 || len(encrypted)%aes.BlockSize != 0 {
+		return nil, errors.New("steam: invalid encrypted maFile")
+	}
+
+	key := pbkdf2(sha1.New, []byte(passkey), saltBytes, maFileKeyIterations, maFileKeySize)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, len(encrypted))
+	cipher.NewCBCDecrypter(block, ivBytes).CryptBlocks(plain, encrypted)
+
+	plain, ok := unpad(plain)
+	if !ok {
+		return nil, ErrMaFilePasskey
+	}
+	m, err := ParseMaFile(plain)
+	if err != nil {
+		// A wrong key which happens to give valid padding is caught here.
+		return nil, ErrMaFilePasskey
+	}
+	return m, nil
+}
+
+// maFileManifest is the manifest.json kept by Steam Desktop Authenticator
+// next to its maFiles.
+type maFileManifest struct {
+	Encrypted bool `json:"encrypted"`
+	Entries   []struct {
+		Filename       string `json:"filename"`
+		EncryptionIV   string `json:"encryption_iv"`
+		EncryptionSalt string `json:"encryption_salt"`
+	} `json:"entries"`
+}
+
+// LoadMaFile reads the maFile at path. If the file is encrypted, passkey is
+// used to decrypt it with the salt and iv found in the manifest.json of the
+// same directory.
+func LoadMaFile(path, passkey string) (*MaFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return ParseMaFile(data)
+	}
+	if passkey == "" {
+		return nil, ErrMaFileEncrypted
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(filepath.Dir(path), "manifest.json"))
+	if err != nil {
+		return nil, err
+	}
+	var manifest maFileManifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, err
+	}
+	for _, entry := range manifest.Entries {
+		if entry.Filename == filepath.Base(path) {
+			return DecryptMaFile(data, passkey, entry.EncryptionSalt, entry.EncryptionIV)
+		}
+	}
+	return nil, errors.New("steam: " + filepath.Base(path) + " is not in manifest.json")
+}
+
+// unpad removes the PKCS#7 padding of data. ok is false if the padding is
+// invalid.
+func unpad(data []byte) (unpadded []byte, ok bool) {
+	if len(data) == 0 {
+		return nil, false
+	}
+	n := int(data[len(data)-1])
+	if n == 0 || n > aes.BlockSize || n > len(data) {
+		return nil, false
+	}
+	for _, b := range data[len(data)-n:] {
+		if int(b) != n {
+			return nil, false
+		}
+	}
+	return data[:len(data)-n], true
+}
+
+// pbkdf2 derives a key of keyLen bytes from password and salt as described
+// in RFC 8018, using HMAC with h.
+func pbkdf2(h func() hash.Hash, password, salt []byte, iter, keyLen int) []byte {
+	prf := hmac.New(h, password)
+	hashLen := prf.Size()
+	numBlocks := (keyLen + hashLen - 1) / hashLen
+
+	var buf [4]byte
+	key := make([]byte, 0, numBlocks*hashLen)
+	u := make([]byte, hashLen)
+	for block := 1; block <= numBlocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf[:], uint32(block))
+		prf.Write(buf[:])
+		key = prf.Sum(key)
+		t := key[len(key)-hashLen:]
+		copy(u, t)
+
+		for n := 2; n <= iter; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = u[:0]
+			u = prf.Sum(u)
+			for i := range u {
+				t[i] ^= u[i]
+			}
+		}
+	}
+	return key[:keyLen]
+}
@@ -0,0 +1,127 @@
+package steam
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// An maFile encrypted as Steam Desktop Authenticator does, with the passkey
+// "correct horse", and its manifest entry.
+const (
+	encryptedMaFile    = "u7SJXv9cPjOOypzAoCvbEqd/x9tZzlMmjdTYYxtBu9VmRQwvGFbIk85DKZwuVkG7/n9mQFy12tSi/c6t3/wRsD1LrAxa8/rjNdoYmMyd+xw3vl49ugwpBwTRDruap1nXnSnz2V+vgQm0K1HYh0bOtotgfZOw7qNjOQDxuQJMCgWBwdf457z3O1XhUXic1EAYJbyqNoIaErAZW2kDzrtAsDHaeY6iL6C1eTQ136qwKqUNrzD4Gn8uE6K71kXGE/C/hxqN6UNh2ffKN6opGEZqVFd0VzqXmSeqO8GTo1wJEGVcdU2xzGvypOdn9OCanI7Qy5nQ3zLo8b95cznIDw2K5A=="
+	maFileSalt         = "AAECAwQFBgc="
+	maFileIV           = "ZGVmZ2hpamtsbW5vcHFycw=="
+	maFileManifestJSON = `{"encrypted":true,"entries":[{"encryption_iv":"` + maFileIV + `","encryption_salt":"` + maFileSalt + `","filename":"76561198000000001.maFile","steamid":76561198000000001}]}`
+)
+
+// checkMaFile reports whether m holds the secrets of encryptedMaFile.
+func checkMaFile(t *testing.T, m *MaFile) {
+	t.Helper()
+	if m.SharedSecret != "AQIDBAUGBwgJCgsMDQ4PEBESExQ=" || m.IdentitySecret != "FBMSERAPDg0MCwoJCAcGBQQDAgE=" ||
+		m.DeviceID != "android:00000000-1111-2222-3333-444444444444" || m.AccountName != "bot" ||
+		m.RevocationCode != "R12345" || m.SteamID() != 76561198000000001 {
+		t.Errorf("decrypted maFile = %+v", m)
+	}
+}
+
+func TestDecryptMaFile(t *testing.T) {
+	m, err := DecryptMaFile([]byte(encryptedMaFile), "correct horse", maFileSalt, maFileIV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMaFile(t, m)
+
+	for i := 0; i < 20; i++ {
+		passkey := "wrong horse " + strconv.Itoa(i)
+		if m, err := DecryptMaFile([]byte(encryptedMaFile), passkey, maFileSalt, maFileIV); !errors.Is(err, ErrMaFilePasskey) {
+			t.Errorf("DecryptMaFile with %q = %+v, %v, want ErrMaFilePasskey", passkey, m, err)
+		}
+	}
+
+	if _, err := DecryptMaFile([]byte(encryptedMaFile[:40]), "correct horse", maFileSalt, maFileIV); err == nil {
+		t.Error("DecryptMaFile of a truncated file succeeded")
+	}
+	if _, err := DecryptMaFile([]byte(encryptedMaFile), "correct horse", maFileSalt, maFileSalt); err == nil {
+		t.Error("DecryptMaFile with an 8 byte iv succeeded")
+	}
+}
+
+func TestLoadMaFile(t *testing.T) {
+	dir := t.TempDir()
+	encrypted := filepath.Join(dir, "76561198000000001.maFile")
+	ioutil.WriteFile(encrypted, []byte(encryptedMaFile), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "manifest.json"), []byte(maFileManifestJSON), 0600)
+
+	m, err := LoadMaFile(encrypted, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMaFile(t, m)
+
+	if _, err := LoadMaFile(encrypted, ""); !errors.Is(err, ErrMaFileEncrypted) {
+		t.Errorf("LoadMaFile without a passkey: %v, want ErrMaFileEncrypted", err)
+	}
+	if _, err := LoadMaFile(encrypted, "wrong horse"); !errors.Is(err, ErrMaFilePasskey) {
+		t.Errorf("LoadMaFile with a wrong passkey: %v, want ErrMaFilePasskey", err)
+	}
+
+	// An encrypted file missing from the manifest.
+	unknown := filepath.Join(dir, "76561198000000002.maFile")
+	ioutil.WriteFile(unknown, []byte(encryptedMaFile), 0600)
+	if m, err := LoadMaFile(unknown, "correct horse"); err == nil {
+		t.Errorf("LoadMaFile of a file missing from the manifest = %+v", m)
+	}
+
+	plain := filepath.Join(t.TempDir(), "plain.maFile")
+	ioutil.WriteFile(plain, []byte(`{"shared_secret":"AQIDBAUGBwgJCgsMDQ4PEBESExQ=","identity_secret":"FBMSERAPDg0MCwoJCAcGBQQDAgE=","account_name":"bot","Session":{"SteamID":76561198000000001}}`), 0600)
+	if m, err := LoadMaFile(plain, ""); err != nil || m.SharedSecret != "AQIDBAUGBwgJCgsMDQ4PEBESExQ=" || m.SteamID() != 76561198000000001 {
+		t.Errorf("LoadMaFile of a plaintext maFile = %+v, %v", m, err)
+	}
+}
+
+func TestUnpad(t *testing.T) {
+	for _, test := range []struct {
+		data string
+		want string // the unpadded data in hex, or "-" if the padding is invalid
+	}{
+		{"61626301", "616263"},
+		{"61020202", "6102"},
+		{"10101010101010101010101010101010", ""},
+		{"", "-"},
+		{"616200", "-"},
+		{"61626311", "-"}, // longer than a block
+		{"0404", "-"},     // longer than the data
+		{"61620302", "-"},
+	} {
+		data, _ := hex.DecodeString(test.data)
+		got, ok := unpad(data)
+		if !ok && test.want != "-" || ok && hex.EncodeToString(got) != test.want {
+			t.Errorf("unpad(%s) = %x, %v, want %s", test.data, got, ok, test.want)
+		}
+	}
+}
+
+func TestPBKDF2(t *testing.T) {
+	// The test vectors of RFC 6070.
+	for _, test := range []struct {
+		password, salt string
+		iter, keyLen   int
+		key            string
+	}{
+		{"password", "salt", 1, 20, "0c60c80f961f0e71f3a9b524af6012062fe037a6"},
+		{"password", "salt", 2, 20, "ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957"},
+		{"password", "salt", 4096, 20, "4b007901b765489abead49d926f721d065a429c1"},
+		{"passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096, 25, "3d2eec4fe41c849b80c8d83662c0e44a8b291a964cf2f07038"},
+		{"pass\x00word", "sa\x00lt", 4096, 16, "56fa6aa75548099dcc37d7f03425e0c3"},
+	} {
+		key := pbkdf2(sha1.New, []byte(test.password), []byte(test.salt), test.iter, test.keyLen)
+		if hex.EncodeToString(key) != test.key {
+			t.Errorf("pbkdf2(%q, %q, %d) = %x, want %s", test.password, test.salt, test.iter, key, test.key)
+		}
+	}
+}
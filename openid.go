@@ -0,0 +1,358 @@
+package steam
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The OpenID 2.0 values used by Steam.
+const (
+	openIDNamespace        = "http://specs.openid.net/auth/2.0"
+	openIDIdentifierSelect = "http://specs.openid.net/auth/2.0/identifier_select"
+)
+
+// DefaultOpenIDNonceMaxAge is how old a sign-in assertion accepted by an
+// OpenID with no NonceMaxAge can be.
+const DefaultOpenIDNonceMaxAge = 5 * time.Minute
+
+// ErrOpenIDRejected is returned when a "Sign in through Steam" callback is
+// not a valid assertion. Use errors.Is to check for it; the error returned is
+// an *OpenIDError telling why.
+var ErrOpenIDRejected = errors.New("steam: OpenID sign-in rejected")
+
+// OpenIDError is returned when a "Sign in through Steam" callback is rejected.
+type OpenIDError struct {
+	Reason string
+}
+
+func (e *OpenIDError) Error() string {
+	return "steam: OpenID sign-in rejected: " + e.Reason
+}
+
+// Is reports whether target is ErrOpenIDRejected.
+func (e *OpenIDError) Is(target error) bool {
+	return target == ErrOpenIDRejected
+}
+
+// OpenID is the relying party of "Sign in through Steam", which redirects
+// users to Steam and verifies the assertions Steam sends them back with. It
+// remembers the nonces of the assertions it accepted to refuse replays, so a
+// single OpenID should be used for every callback.
+type OpenID struct {
+	// Realm is the URL pattern users are asked to trust, eg.
+	// "https://example.com/" or "https://*.example.com/".
+	Realm string
+
+	// ReturnTo is the URL Steam sends users back to, which must be within
+	// Realm.
+	ReturnTo string
+
+	// Client provides the community base URL and the HTTP client used to
+	// check assertions. If nil, DefaultClient is used.
+	Client *Client
+
+	// NonceMaxAge is how old an assertion can be. If zero,
+	// DefaultOpenIDNonceMaxAge is used.
+	NonceMaxAge time.Duration
+
+	mu     sync.Mutex
+	nonces map[string]time.Time // the nonces accepted, by expiry
+}
+
+// NewOpenID returns an OpenID for realm which sends users back to returnTo.
+func NewOpenID(realm, returnTo string) *OpenID {
+	return &OpenID{Realm: realm, ReturnTo: returnTo}
+}
+
+// OpenIDLoginURL returns the URL to redirect users to for signing in through
+// Steam. Steam sends them back to returnTo, which must be within realm; the
+// callback is verified with an OpenID.
+func OpenIDLoginURL(realm, returnTo string) string {
+	return NewOpenID(realm, returnTo).LoginURL()
+}
+
+// client returns the Client used by o.
+func (o *OpenID) client() *Client {
+	if o.Client != nil {
+		return o.Client
+	}
+	return DefaultClient
+}
+
+// endpoint returns the URL of the Steam OpenID provider.
+func (o *OpenID) endpoint() string {
+	return o.client().communityURL("openid/login", nil)
+}
+
+// LoginURL returns the URL to redirect users to for signing in through Steam.
+func (o *OpenID) LoginURL() string {
+	return o.client().communityURL("openid/login", url.Values{
+		"openid.ns":         {openIDNamespace},
+		"openid.mode":       {"checkid_setup"},
+		"openid.return_to":  {o.ReturnTo},
+		"openid.realm":      {o.Realm},
+		"openid.identity":   {openIDIdentifierSelect},
+		"openid.claimed_id": {openIDIdentifierSelect},
+	})
+}
+
+// Verify checks the assertion in the query of a callback to ReturnTo with
+// Steam and returns the SteamID64 of the user who signed in.
+func (o *OpenID) Verify(query url.Values) (SteamID64, error) {
+	return o.VerifyContext(context.Background(), query)
+}
+
+// VerifyContext is like Verify but its requests are bound to ctx.
+func (o *OpenID) VerifyContext(ctx context.Context, query url.Values) (SteamID64, error) {
+	steam64, err := o.checkAssertion(query)
+	if err != nil {
+		return 0, err
+	}
+	nonce := query.Get("openid.response_nonce")
+	expiry, err := o.checkNonce(nonce)
+	if err != nil {
+		return 0, err
+	}
+
+	// Ask Steam whether it made the assertion, as only it knows the key of
+	// the signature.
+	form := url.Values{}
+	for key, values := range query {
+		if strings.HasPrefix(key, "openid.") {
+			form[key] = values
+		}
+	}
+	form.Set("openid.mode", "check_authentication")
+
+	c := o.client()
+	req, err := c.newRequest(ctx, "POST", o.endpoint(), form)
+	if err != nil {
+		return 0, err
+	}
+	content, err := c.do(c.httpClient(), req)
+	if err != nil {
+		return 0, err
+	}
+
+	fields := parseKeyValueForm(content)
+	if fields["ns"] != openIDNamespace || fields["is_valid"] != "true" {
+		return 0, &OpenIDError{Reason: "assertion not confirmed by Steam"}
+	}
+
+	// The nonce is only used up once Steam confirmed the assertion, so that
+	// a failed check can be tried again.
+	if err := o.useNonce(nonce, expiry); err != nil {
+		return 0, err
+	}
+	return steam64, nil
+}
+
+// checkAssertion checks the fields of the assertion in query which can be
+// verified without asking Steam, and returns the SteamID64 asserted.
+func (o *OpenID) checkAssertion(query url.Values) (SteamID64, error) {
+	switch query.Get("openid.mode") {
+	case "id_res":
+	case "cancel":
+		return 0, &OpenIDError{Reason: "cancelled by the user"}
+	default:
+		return 0, &OpenIDError{Reason: "not a positive assertion"}
+	}
+	if query.Get("openid.ns") != openIDNamespace {
+		return 0, &OpenIDError{Reason: "wrong namespace"}
+	}
+	if query.Get("openid.op_endpoint") != o.endpoint() {
+		return 0, &OpenIDError{Reason: "wrong provider endpoint"}
+	}
+
+	// Fields which are not signed could have been changed by anyone.
+	signed := make(map[string]bool)
+	for _, field := range strings.Split(query.Get("openid.signed"), ",") {
+		signed[field] = true
+	}
+	for _, field := range []string{"op_endpoint", "claimed_id", "identity", "return_to", "response_nonce", "assoc_handle"} {
+		if !signed[field] {
+			return 0, &OpenIDError{Reason: "openid." + field + " is not signed"}
+		}
+	}
+
+	if err := o.checkReturnTo(query); err != nil {
+		return 0, err
+	}
+
+	claimedID := query.Get("openid.claimed_id")
+	if claimedID != query.Get("openid.identity") {
+		return 0, &OpenIDError{Reason: "identity does not match claimed_id"}
+	}
+	prefix := o.client().communityURL("openid/id/", nil)
+	if !strings.HasPrefix(claimedID, prefix) {
+		return 0, &OpenIDError{Reason: "claimed_id is not a Steam identity"}
+	}
+	steam64, err := strconv.ParseUint(strings.TrimPrefix(claimedID, prefix), 10, 64)
+	if err != nil || steam64 == 0 {
+		return 0, &OpenIDError{Reason: "claimed_id is not a Steam identity"}
+	}
+	return SteamID64(steam64), nil
+}
+
+// checkReturnTo checks that the assertion in query was made for o.
+func (o *OpenID) checkReturnTo(query url.Values) error {
+	returnTo, err := url.Parse(query.Get("openid.return_to"))
+	if err != nil {
+		return &OpenIDError{Reason: "invalid return_to"}
+	}
+	expected, err := url.Parse(o.ReturnTo)
+	if err != nil {
+		return err
+	}
+	if returnTo.Scheme != expected.Scheme || returnTo.Host != expected.Host || returnTo.Path != expected.Path {
+		return &OpenIDError{Reason: "return_to does not match"}
+	}
+	// The parameters of return_to must be those the callback was called
+	// with, or they could have been added by someone else.
+	for key, values := range returnTo.Query() {
+		if strings.Join(query[key], "\x00") != strings.Join(values, "\x00") {
+			return &OpenIDError{Reason: "return_to parameter " + key + " does not match"}
+		}
+	}
+	if !realmMatches(o.Realm, returnTo) {
+		return &OpenIDError{Reason: "return_to is not within the realm"}
+	}
+	return nil
+}
+
+// realmMatches reports whether u is within the OpenID realm.
+func realmMatches(realm string, u *url.URL) bool {
+	r, err := url.Parse(realm)
+	if err != nil || r.Scheme != u.Scheme || r.Fragment != "" {
+		return false
+	}
+
+	host := u.Hostname()
+	realmHost := r.Hostname()
+	if strings.HasPrefix(r.Host, "*.") {
+		realmHost = strings.TrimPrefix(realmHost, "*.")
+		if host != realmHost && !strings.HasSuffix(host, "."+realmHost) {
+			return false
+		}
+	} else if host != realmHost {
+		return false
+	}
+	if r.Port() != u.Port() {
+		return false
+	}
+
+	realmPath := r.Path
+	if realmPath == "" {
+		realmPath = "/"
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	if path == realmPath {
+		return true
+	}
+	if !strings.HasSuffix(realmPath, "/") {
+		realmPath += "/"
+	}
+	return strings.HasPrefix(path, realmPath)
+}
+
+// checkNonce checks the age of the response nonce of an assertion and that it
+// was not used before. It returns when the nonce becomes too old to be
+// accepted.
+func (o *OpenID) checkNonce(nonce string) (expiry time.Time, err error) {
+	if len(nonce) < len("2006-01-02T15:04:05Z") {
+		return time.Time{}, &OpenIDError{Reason: "invalid response_nonce"}
+	}
+	issued, err := time.Parse("2006-01-02T15:04:05Z", nonce[:len("2006-01-02T15:04:05Z")])
+	if err != nil {
+		return time.Time{}, &OpenIDError{Reason: "invalid response_nonce"}
+	}
+
+	maxAge := o.NonceMaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultOpenIDNonceMaxAge
+	}
+	now := time.Now()
+	// Allow for the clock of Steam being slightly ahead.
+	if now.Sub(issued) > maxAge || issued.Sub(now) > time.Minute {
+		return time.Time{}, &OpenIDError{Reason: "response_nonce expired"}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.nonces[nonce]; ok {
+		return time.Time{}, &OpenIDError{Reason: "response_nonce already used"}
+	}
+	return issued.Add(maxAge), nil
+}
+
+// useNonce remembers nonce until expiry to refuse replays. An error is
+// returned if nonce was used since checkNonce.
+func (o *OpenID) useNonce(nonce string, expiry time.Time) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.nonces == nil {
+		o.nonces = make(map[string]time.Time)
+	}
+	now := time.Now()
+	for n, e := range o.nonces {
+		if now.After(e) {
+			delete(o.nonces, n)
+		}
+	}
+	if _, ok := o.nonces[nonce]; ok {
+		return &OpenIDError{Reason: "response_nonce already used"}
+	}
+	o.nonces[nonce] = expiry
+	return nil
+}
+
+// parseKeyValueForm parses an OpenID key-value form, made of "key:value"
+// lines.
+func parseKeyValueForm(content []byte) map[string]string {
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, ':'); i > 0 {
+			fields[line[:i]] = line[i+1:]
+		}
+	}
+	return fields
+}
+
+// Handler returns an http.Handler for ReturnTo and the sign-in link of a
+// site. Requests without an assertion are redirected to Steam; callbacks are
+// verified and passed to success with the SteamID64 of the user, or to
+// failure with the error. If failure is nil, a 403 Forbidden is sent.
+func (o *OpenID) Handler(success func(w http.ResponseWriter, r *http.Request, steam64 SteamID64), failure func(w http.ResponseWriter, r *http.Request, err error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("openid.mode") == "" {
+			http.Redirect(w, r, o.LoginURL(), http.StatusFound)
+			return
+		}
+
+		steam64, err := o.VerifyContext(r.Context(), query)
+		if err != nil {
+			if failure != nil {
+				failure(w, r, err)
+				return
+			}
+			http.Error(w, "Sign in through Steam failed.", http.StatusForbidden)
+			return
+		}
+		success(w, r, steam64)
+	})
+}
@@ -0,0 +1,56 @@
+package steam_test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/Acidic9/steam"
+	"github.com/Acidic9/steam/steamtest"
+)
+
+func TestOpenIDVerify(t *testing.T) {
+	srv := steamtest.New()
+	defer srv.Close()
+	openID := steam.NewOpenID("https://example.com/", "https://example.com/callback")
+	openID.Client = srv.Client()
+
+	// signIn returns the query of the callback of botID signing in.
+	signIn := func() url.Values {
+		callback, err := srv.SignInOpenID(openID.LoginURL(), botID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, err := url.Parse(callback)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u.Query()
+	}
+
+	query := signIn()
+	if steam64, err := openID.Verify(query); err != nil || steam64 != botID {
+		t.Fatalf("Verify = %v, %v, want %v", steam64, err, botID)
+	}
+	if _, err := openID.Verify(query); !errors.Is(err, steam.ErrOpenIDRejected) {
+		t.Errorf("Verify of a replay: %v, want ErrOpenIDRejected", err)
+	}
+
+	forged := signIn()
+	forged.Set("openid.claimed_id", "https://steamcommunity.com/openid/id/76561198000000002")
+	forged.Set("openid.identity", forged.Get("openid.claimed_id"))
+	if _, err := openID.Verify(forged); !errors.Is(err, steam.ErrOpenIDRejected) {
+		t.Errorf("Verify of a forged assertion: %v, want ErrOpenIDRejected", err)
+	}
+
+	// The nonce is not used up by a check which failed.
+	query = signIn()
+	srv.Inject(steamtest.Fault{Path: "/openid/login", StatusCode: http.StatusServiceUnavailable, Times: 1})
+	if _, err := openID.Verify(query); err == nil {
+		t.Fatal("Verify succeeded while Steam was unavailable")
+	}
+	if steam64, err := openID.Verify(query); err != nil || steam64 != botID {
+		t.Errorf("Verify after a failed check = %v, %v, want %v", steam64, err, botID)
+	}
+}
@@ -0,0 +1,320 @@
+package steam
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The limits of a MessageQueue which has none.
+var (
+	// DefaultMessageRateLimit limits the messages sent to every recipient.
+	DefaultMessageRateLimit = RateLimit{Rate: 1, Burst: 5}
+
+	// DefaultRecipientRateLimit limits the messages sent to a single
+	// recipient.
+	DefaultRecipientRateLimit = RateLimit{Rate: 0.5, Burst: 3}
+
+	// DefaultMessageRetryPolicy controls the retries of messages which did
+	// not reach Steam.
+	DefaultMessageRetryPolicy = RetryPolicy{
+		MaxRetries: 3,
+		MinBackoff: time.Second,
+		MaxBackoff: 30 * time.Second,
+	}
+)
+
+// DefaultMaxMessageLength is the length, in characters, at which a
+// MessageQueue with no MaxLength splits messages.
+const DefaultMaxMessageLength = 2048
+
+// MessageResult is the result of the delivery of a message.
+type MessageResult struct {
+	Recipient SteamID64
+	Text      string
+	Err       error // nil if every part of the message was sent
+}
+
+// BroadcastError is returned when a message could not be delivered to some
+// of its recipients.
+type BroadcastError struct {
+	Sent   int             // the number of recipients the message was delivered to
+	Failed []MessageResult // the results of the other recipients
+}
+
+func (e *BroadcastError) Error() string {
+	msg := "steam: message not delivered to " + strconv.Itoa(len(e.Failed)) + " of " + strconv.Itoa(len(e.Failed)+e.Sent) + " recipients"
+	if len(e.Failed) > 0 {
+		msg += ": " + strconv.FormatUint(uint64(e.Failed[0].Recipient), 10) + ": " + e.Failed[0].Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the errors of the failed deliveries.
+func (e *BroadcastError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, r := range e.Failed {
+		errs = append(errs, r.Err)
+	}
+	return errs
+}
+
+// MessageQueue sends the chat messages of an Account at a pace Steam
+// accepts. Messages to a recipient are delivered in the order they were
+// queued, while different recipients are served concurrently. Messages
+// longer than MaxLength are sent in several parts, and sends which did not
+// reach Steam are retried.
+//
+// The Account sends Message and Broadcast through its MessageQueue, so the
+// limits apply to every message it sends.
+type MessageQueue struct {
+	Account *Account
+
+	// RateLimit limits the messages sent to every recipient, and
+	// RecipientRateLimit those sent to a single one. Each part of a split
+	// message counts as a message. If the zero value,
+	// DefaultMessageRateLimit and DefaultRecipientRateLimit are used.
+	RateLimit          RateLimit
+	RecipientRateLimit RateLimit
+
+	// Retry controls the retries of messages refused with 429 Too Many
+	// Requests or which could not be sent, eg. because the connection to
+	// Steam failed. Other failures are not retried, as the message may have
+	// been delivered. If the zero value, DefaultMessageRetryPolicy is used.
+	// The Client of the Account does not retry messages itself.
+	Retry RetryPolicy
+
+	// MaxLength is the length, in characters, at which messages are split.
+	// If zero, DefaultMaxMessageLength is used.
+	MaxLength int
+
+	// OnResult, if set, is called with the result of every message.
+	OnResult func(r MessageResult)
+
+	mu      sync.Mutex
+	pending map[SteamID64][]*queuedMessage // the messages waiting, by recipient
+
+	limiterOnce sync.Once
+	limiter     *Limiter // keyed by recipient; "" is the global limit
+}
+
+// queuedMessage is a message waiting in a MessageQueue.
+type queuedMessage struct {
+	ctx       context.Context
+	recipient SteamID64
+	text      string
+	result    chan MessageResult
+}
+
+// NewMessageQueue returns a MessageQueue sending the messages of acc with the
+// default limits.
+func NewMessageQueue(acc *Account) *MessageQueue {
+	return &MessageQueue{Account: acc}
+}
+
+// messageQueue returns the MessageQueue of acc, creating it on first use.
+func (acc *Account) messageQueue() *MessageQueue {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	if acc.MessageQueue == nil {
+		acc.MessageQueue = NewMessageQueue(acc)
+	}
+	return acc.MessageQueue
+}
+
+// Send queues text for recipient and returns a channel receiving the result
+// of its delivery. The message is dropped if ctx is done before it is sent.
+func (q *MessageQueue) Send(ctx context.Context, recipient SteamID64, text string) <-chan MessageResult {
+	m := &queuedMessage{
+		ctx:       ctx,
+		recipient: recipient,
+		text:      text,
+		result:    make(chan MessageResult, 1),
+	}
+
+	q.mu.Lock()
+	if q.pending == nil {
+		q.pending = make(map[SteamID64][]*queuedMessage)
+	}
+	idle := len(q.pending[recipient]) == 0
+	q.pending[recipient] = append(q.pending[recipient], m)
+	q.mu.Unlock()
+
+	// Each recipient with pending messages has a goroutine delivering them.
+	if idle {
+		go q.deliver(recipient)
+	}
+	return m.result
+}
+
+// SendAll queues text for every recipient and returns the results once
+// every message was delivered or failed.
+func (q *MessageQueue) SendAll(ctx context.Context, recipients []SteamID64, text string) []MessageResult {
+	results := make([]<-chan MessageResult, 0, len(recipients))
+	for _, recipient := range recipients {
+		results = append(results, q.Send(ctx, recipient, text))
+	}
+
+	delivered := make([]MessageResult, 0, len(recipients))
+	for _, result := range results {
+		delivered = append(delivered, <-result)
+	}
+	return delivered
+}
+
+// deliver sends the pending messages of recipient in order, until there are
+// none left.
+func (q *MessageQueue) deliver(recipient SteamID64) {
+	for {
+		q.mu.Lock()
+		m := q.pending[recipient][0]
+		q.mu.Unlock()
+
+		r := MessageResult{Recipient: recipient, Text: m.text, Err: q.send(m)}
+		m.result <- r
+		if q.OnResult != nil {
+			q.OnResult(r)
+		}
+
+		q.mu.Lock()
+		q.pending[recipient] = q.pending[recipient][1:]
+		if len(q.pending[recipient]) == 0 {
+			delete(q.pending, recipient)
+			q.mu.Unlock()
+			// Forget the limits of the recipients which are idle, so that a
+			// bot messaging many users does not keep a bucket for each.
+			q.rateLimiter().prune()
+			return
+		}
+		q.mu.Unlock()
+	}
+}
+
+// send sends every part of m, stopping at the first failure.
+func (q *MessageQueue) send(m *queuedMessage) error {
+	for _, part := range splitMessage(m.text, q.maxLength()) {
+		if err := q.sendPart(m.ctx, m.recipient, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendPart sends a single part of a message within the limits of q,
+// retrying transient failures.
+func (q *MessageQueue) sendPart(ctx context.Context, recipient SteamID64, text string) error {
+	policy := q.Retry
+	if policy == (RetryPolicy{}) {
+		policy = DefaultMessageRetryPolicy
+	}
+	limiter := q.rateLimiter()
+	acc := q.Account
+
+	for attempt := 0; ; attempt++ {
+		// Wait for the recipient first, so that a recipient being throttled
+		// does not hold up the others.
+		if err := limiter.Wait(ctx, strconv.FormatUint(uint64(recipient), 10)); err != nil {
+			return err
+		}
+		if err := limiter.Wait(ctx, ""); err != nil {
+			return err
+		}
+
+		err := acc.withRelogin(ctx, func() error {
+			return acc.message(ctx, recipient, text)
+		})
+		if err == nil || !transientMessageError(err) || attempt >= policy.MaxRetries {
+			return err
+		}
+
+		var retryAfter time.Duration
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) {
+			retryAfter = httpErr.RetryAfter
+		}
+		delay, ok := policy.backoff(attempt, retryAfter)
+		if !ok {
+			return err
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// rateLimiter returns the Limiter of q, which limits each recipient with
+// RecipientRateLimit and every recipient together, under the key "", with
+// RateLimit.
+func (q *MessageQueue) rateLimiter() *Limiter {
+	q.limiterOnce.Do(func() {
+		limit, recipientLimit := q.RateLimit, q.RecipientRateLimit
+		if limit == (RateLimit{}) {
+			limit = DefaultMessageRateLimit
+		}
+		if recipientLimit == (RateLimit{}) {
+			recipientLimit = DefaultRecipientRateLimit
+		}
+		q.limiter = NewLimiter(recipientLimit)
+		q.limiter.SetLimit("", limit)
+	})
+	return q.limiter
+}
+
+// maxLength returns the length at which q splits messages.
+func (q *MessageQueue) maxLength() int {
+	if q.MaxLength > 0 {
+		return q.MaxLength
+	}
+	return DefaultMaxMessageLength
+}
+
+// transientMessageError reports whether a message which failed with err can
+// be sent again without being delivered twice: Steam refused it with 429 Too
+// Many Requests, or it was never sent as the connection failed. After other
+// errors, such as a server error or a timeout, the message may have been
+// delivered.
+func transientMessageError(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// splitMessage splits text into parts of at most max characters, breaking
+// at whitespace where possible.
+func splitMessage(text string, max int) []string {
+	if utf8.RuneCountInString(text) <= max {
+		return []string{text}
+	}
+
+	var parts []string
+	runes := []rune(text)
+	for len(runes) > max {
+		cut := max
+		// Break at the last whitespace of the part, unless that leaves it
+		// less than half full.
+		for i := max; i > max/2; i-- {
+			if unicode.IsSpace(runes[i]) {
+				cut = i
+				break
+			}
+		}
+		if part := strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace); part != "" {
+			parts = append(parts, part)
+		}
+		runes = []rune(strings.TrimLeftFunc(string(runes[cut:]), unicode.IsSpace))
+	}
+	if len(runes) > 0 {
+		parts = append(parts, string(runes))
+	}
+	return parts
+}
@@ -0,0 +1,97 @@
+package steam
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTransientMessageError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&HTTPError{StatusCode: 429}, true},
+		{&HTTPError{StatusCode: 503}, false},
+		{&HTTPError{StatusCode: 500}, false},
+		{&url.Error{Op: "Post", URL: "https://api.steampowered.com/", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}, true},
+		{&url.Error{Op: "Post", URL: "https://api.steampowered.com/", Err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}}, false},
+		{context.DeadlineExceeded, false},
+		{ErrSessionExpired, false},
+	}
+	for _, test := range tests {
+		if got := transientMessageError(test.err); got != test.want {
+			t.Errorf("transientMessageError(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
+
+func TestLimiterPrune(t *testing.T) {
+	l := NewLimiter(RateLimit{Rate: 1, Burst: 2})
+	l.SetLimit("unlimited", RateLimit{})
+	for _, host := range []string{"a", "b", "unlimited"} {
+		l.Wait(context.Background(), host)
+	}
+	l.prune()
+	if n := len(l.buckets); n != 2 {
+		t.Fatalf("%d buckets after prune, want the 2 which are not full", n)
+	}
+
+	l.buckets["a"].last = time.Now().Add(-2 * time.Second)
+	l.prune()
+	if _, ok := l.buckets["a"]; ok || len(l.buckets) != 1 {
+		t.Errorf("buckets after a refilled: %v", l.buckets)
+	}
+}
+
+func TestMessageQueueForgetsRecipients(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"error":"OK"}`))
+	}))
+	defer srv.Close()
+
+	acc := &Account{
+		Client:      &Client{ApiBaseURL: srv.URL, Limiter: NewLimiter(RateLimit{})},
+		HttpClient:  http.DefaultClient,
+		Umqid:       "1",
+		AccessToken: "token",
+	}
+	q := &MessageQueue{
+		Account:            acc,
+		RateLimit:          RateLimit{Rate: 1000, Burst: 1000},
+		RecipientRateLimit: RateLimit{Rate: 1000, Burst: 1},
+	}
+	recipients := make([]SteamID64, 100)
+	for i := range recipients {
+		recipients[i] = 76561198000000001 + SteamID64(i)
+	}
+	for _, r := range q.SendAll(context.Background(), recipients, "hello") {
+		if r.Err != nil {
+			t.Fatal(r.Err)
+		}
+	}
+
+	// The buckets of the recipients refill within a millisecond and are
+	// removed once the queue of another recipient empties.
+	time.Sleep(10 * time.Millisecond)
+	if err := (<-q.Send(context.Background(), 76561198000000500, "hello")).Err; err != nil {
+		t.Fatal(err)
+	}
+	// The result is sent before the queue empties.
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		q.limiter.mu.Lock()
+		n := len(q.limiter.buckets)
+		q.limiter.mu.Unlock()
+		if n <= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d buckets kept after messaging %d recipients", n, len(recipients)+1)
+		}
+	}
+}
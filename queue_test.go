@@ -0,0 +1,51 @@
+package steam_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Acidic9/steam"
+	"github.com/Acidic9/steam/steamtest"
+)
+
+func TestMessageRetries(t *testing.T) {
+	const path = "/ISteamWebUserPresenceOAuth/Message/"
+	tests := []struct {
+		name     string
+		fault    steamtest.Fault
+		requests int // the number of messages posted
+		status   int // the status of the error, or 0 if the message is delivered
+	}{
+		// The message may have been delivered before the error.
+		{"server error", steamtest.Fault{Path: path, StatusCode: http.StatusServiceUnavailable}, 1, http.StatusServiceUnavailable},
+		{"rate limited", steamtest.Fault{Path: path, StatusCode: http.StatusTooManyRequests, Times: 2}, 3, 0},
+		// The Client does not retry on top of the MessageQueue.
+		{"rate limited until giving up", steamtest.Fault{Path: path, StatusCode: http.StatusTooManyRequests}, 3, http.StatusTooManyRequests},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := newServer(t)
+			acc, _ := login(t, srv)
+			acc.MessageQueue.Retry = steam.RetryPolicy{MaxRetries: 2, MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+			srv.Inject(test.fault)
+			err := acc.Message(friendIDs[0], "hello")
+			var httpErr *steam.HTTPError
+			if test.status == 0 && err != nil || test.status != 0 && (!errors.As(err, &httpErr) || httpErr.StatusCode != test.status) {
+				t.Errorf("Message: %v, want status %d", err, test.status)
+			}
+
+			requests := 0
+			for _, r := range srv.Requests() {
+				if r.Path == path+"v0001/" {
+					requests++
+				}
+			}
+			if requests != test.requests {
+				t.Errorf("the message was posted %d times, want %d", requests, test.requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package steam
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit is the rate at which requests may be sent to a host.
+type RateLimit struct {
+	Rate  float64 // requests per second; 0 means unlimited
+	Burst int     // requests which may be sent at once; values below 1 are treated as 1
+}
+
+// The rate limits used by NewClient. Steam allows roughly 100,000 Web API
+// calls a day per key and throttles the community website much sooner.
+var (
+	DefaultApiRateLimit       = RateLimit{Rate: 4, Burst: 10}
+	DefaultCommunityRateLimit = RateLimit{Rate: 1, Burst: 5}
+)
+
+// Limiter paces requests with a token bucket per host.
+// It is safe for concurrent use and may be shared between Clients.
+type Limiter struct {
+	// Default is the limit of hosts without a limit of their own.
+	Default RateLimit
+
+	mu      sync.Mutex
+	limits  map[string]RateLimit
+	buckets map[string]*bucket
+}
+
+// bucket is the token bucket of a single host.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a Limiter using defaultLimit for every host.
+func NewLimiter(defaultLimit RateLimit) *Limiter {
+	return &Limiter{Default: defaultLimit}
+}
+
+// SetLimit sets the limit of requests sent to host (eg. "api.steampowered.com").
+func (l *Limiter) SetLimit(host string, limit RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limits == nil {
+		l.limits = make(map[string]RateLimit)
+	}
+	l.limits[host] = limit
+	delete(l.buckets, host)
+}
+
+// Wait blocks until a request may be sent to host or ctx is done.
+func (l *Limiter) Wait(ctx context.Context, host string) error {
+	l.mu.Lock()
+	limit, ok := l.limits[host]
+	if !ok {
+		limit = l.Default
+	}
+	if limit.Rate <= 0 {
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+	burst := limit.burst()
+
+	if l.buckets == nil {
+		l.buckets = make(map[string]*bucket)
+	}
+	b, ok := l.buckets[host]
+	now := time.Now()
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[host] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * limit.Rate
+	if b.tokens > burst {
+		b.tokens = burst
+	}
+	b.last = now
+
+	// Take the token now, even if it has yet to be refilled, so that waiting
+	// requests are served in order.
+	b.tokens--
+	wait := time.Duration(-b.tokens / limit.Rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	if err := sleep(ctx, wait); err != nil {
+		l.mu.Lock()
+		b.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// prune removes the buckets which are full again, as a new bucket starts
+// full anyway, so that the Limiter does not grow with every host it has seen.
+func (l *Limiter) prune() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for host, b := range l.buckets {
+		limit, ok := l.limits[host]
+		if !ok {
+			limit = l.Default
+		}
+		if limit.Rate <= 0 || b.tokens+now.Sub(b.last).Seconds()*limit.Rate >= limit.burst() {
+			delete(l.buckets, host)
+		}
+	}
+}
+
+// burst returns the size of the token bucket of limit.
+func (limit RateLimit) burst() float64 {
+	if limit.Burst < 1 {
+		return 1
+	}
+	return float64(limit.Burst)
+}
+
+// RetryPolicy controls how requests which fail with a rate limit, a server
+// error or a timeout are retried.
+//
+// Requests refused with 429 Too Many Requests are always safe to retry. Server
+// errors and timeouts are only retried for GET requests, since a POST (such as
+// sending a message) may have been carried out before the failure.
+//
+// A server asking with Retry-After for a longer delay than MaxBackoff is not
+// waited for: the request fails at once with the *HTTPError, whose RetryAfter
+// field holds the delay asked for.
+type RetryPolicy struct {
+	MaxRetries int           // retries after the first attempt
+	MinBackoff time.Duration // the delay before the first retry, doubled after each retry
+	MaxBackoff time.Duration // the longest delay, including one asked for with Retry-After
+}
+
+// DefaultRetryPolicy is the RetryPolicy used by NewClient.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	MinBackoff: 500 * time.Millisecond,
+	MaxBackoff: 30 * time.Second,
+}
+
+// retryMode overrides how the requests of a context are retried, for those
+// the rules of RetryPolicy do not fit.
+type retryMode int
+
+const (
+	retryByMethod   retryMode = iota // as described by RetryPolicy
+	retryRateLimits                  // only after 429, as for a POST; for GETs which carry out an action
+	retryNever                       // not at all, as the caller retries
+)
+
+// retryModeKey is the context key of the retryMode of requests.
+type retryModeKey struct{}
+
+// withRetryMode returns a copy of ctx whose requests are retried as mode says.
+func withRetryMode(ctx context.Context, mode retryMode) context.Context {
+	return context.WithValue(ctx, retryModeKey{}, mode)
+}
+
+// retryable reports whether req, which failed with err, should be retried.
+func retryable(req *http.Request, err error) bool {
+	mode, _ := req.Context().Value(retryModeKey{}).(retryMode)
+	if mode == retryNever {
+		return false
+	}
+	idempotent := mode == retryByMethod && (req.Method == "GET" || req.Method == "HEAD")
+
+	if httpErr, ok := err.(*HTTPError); ok {
+		switch httpErr.StatusCode {
+		case http.StatusTooManyRequests:
+			return true
+		case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return idempotent
+		}
+		return false
+	}
+
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return idempotent
+	}
+	return false
+}
+
+// backoff returns the delay before retry number attempt (starting at 0).
+// retryAfter is the delay asked for by the server, if any. ok is false if the
+// server asked for a longer delay than the policy allows.
+func (p RetryPolicy) backoff(attempt int, retryAfter time.Duration) (delay time.Duration, ok bool) {
+	if retryAfter > 0 {
+		if p.MaxBackoff > 0 && retryAfter > p.MaxBackoff {
+			return 0, false
+		}
+		return retryAfter, true
+	}
+
+	delay = p.MinBackoff << uint(attempt)
+	if delay <= 0 || (p.MaxBackoff > 0 && delay > p.MaxBackoff) {
+		delay = p.MaxBackoff
+	}
+	// Equal jitter, between half the delay and the delay itself, keeps
+	// clients which failed together from retrying together.
+	if delay > 1 {
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	}
+	return delay, true
+}
+
+// parseRetryAfter returns the delay asked for by a Retry-After header, which
+// holds either a number of seconds or an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// sleep pauses for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,82 @@
+package steam
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// reloginState guards the automatic relogins of an Account, so that the
+// requests failing together because the session expired cause a single
+// relogin.
+type reloginState struct {
+	mu         sync.Mutex
+	generation uint64 // incremented by each login
+	err        error  // the result of the last relogin
+}
+
+// sessionGeneration returns the generation of the current session of acc. It
+// waits for a relogin in progress to finish.
+func (acc *Account) sessionGeneration() uint64 {
+	acc.relogin.mu.Lock()
+	defer acc.relogin.mu.Unlock()
+	return acc.relogin.generation
+}
+
+// reloginAfter logs acc in again because cause revealed that the session of
+// generation gen expired. If another caller already logged in again since gen,
+// the result of that relogin is returned instead.
+func (acc *Account) reloginAfter(ctx context.Context, gen uint64, cause error) error {
+	acc.relogin.mu.Lock()
+	defer acc.relogin.mu.Unlock()
+
+	if acc.relogin.generation != gen {
+		return acc.relogin.err
+	}
+
+	err := acc.loginAgain(ctx)
+	if acc.OnRelogin != nil {
+		acc.OnRelogin(cause, err)
+	}
+	return err
+}
+
+// loginAgain logs acc in again, starting a new session generation.
+// acc.relogin.mu must be held.
+func (acc *Account) loginAgain(ctx context.Context) error {
+	err := acc.login(ctx)
+	acc.relogin.generation++
+	acc.relogin.err = err
+	acc.resetChat()
+	return err
+}
+
+// maxReloginRetries bounds the times withRelogin runs op again.
+const maxReloginRetries = 3
+
+// withRelogin runs op and, if it fails with ErrSessionExpired, logs in again
+// and runs op once more. op must obtain the session credentials it uses, such
+// as the chat credentials, each time it runs. If the retry fails because
+// another caller logged in again meanwhile, op is retried in that session.
+// Accounts without a Password cannot log in again and return the error of op.
+func (acc *Account) withRelogin(ctx context.Context, op func() error) error {
+	gen := acc.sessionGeneration()
+	err := op()
+	for retries := 0; retries < maxReloginRetries; retries++ {
+		if !errors.Is(err, ErrSessionExpired) || acc.Password == "" {
+			return err
+		}
+		if err := acc.reloginAfter(ctx, gen, err); err != nil {
+			return err
+		}
+
+		gen = acc.sessionGeneration()
+		err = op()
+		if acc.sessionGeneration() == gen {
+			// The session op was retried in is current, so the error
+			// is not caused by a concurrent relogin.
+			return err
+		}
+	}
+	return err
+}
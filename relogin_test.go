@@ -0,0 +1,140 @@
+package steam_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Acidic9/steam"
+	"github.com/Acidic9/steam/steamtest"
+)
+
+func TestReloginAfterExpiry(t *testing.T) {
+	tests := []struct {
+		name string
+		op   func(acc *steam.Account) error
+		sent int // the number of messages or invites sent by op
+	}{
+		{"Message", func(acc *steam.Account) error {
+			return acc.Message(friendIDs[0], "hello")
+		}, 1},
+		{"Broadcast", func(acc *steam.Account) error {
+			return acc.Broadcast("hello")
+		}, len(friendIDs)},
+		{"InviteToGroup", func(acc *steam.Account) error {
+			return acc.InviteToGroup(groupID, friendIDs...)
+		}, len(friendIDs)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := newServer(t)
+			acc, relogins := login(t, srv)
+
+			srv.ExpireSessions()
+			if err := test.op(acc); err != nil {
+				t.Fatal(err)
+			}
+			if n := atomic.LoadInt32(relogins); n != 1 {
+				t.Errorf("%d relogins, want 1", n)
+			}
+			if n := len(srv.Messages()) + len(srv.Invites()); n != test.sent {
+				t.Errorf("%d messages and invites sent, want %d", n, test.sent)
+			}
+		})
+	}
+}
+
+func TestMessageAfterChatExpiry(t *testing.T) {
+	srv := newServer(t)
+	acc, relogins := login(t, srv)
+	if err := acc.Message(friendIDs[0], "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The umqid expired but not the session, so logging on to the chat
+	// again is enough.
+	srv.ExpireChatSessions()
+	if err := acc.Message(friendIDs[0], "again"); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(relogins); n != 0 {
+		t.Errorf("%d relogins, want 0", n)
+	}
+	if n := len(srv.Messages()); n != 2 {
+		t.Errorf("%d messages sent, want 2", n)
+	}
+}
+
+func TestChatAfterExpiry(t *testing.T) {
+	t.Run("chat session", func(t *testing.T) {
+		srv := newServer(t)
+		acc, relogins := login(t, srv)
+		events, states := startChat(t, acc)
+
+		// The queue is resumed, so the message sent meanwhile is received.
+		srv.ExpireChatSessions()
+		srv.DeliverMessage(friendIDs[0], botID, "hi")
+		waitState(t, states, steam.ChatConnecting)
+		waitMessage(t, events, friendIDs[0], "hi")
+		if n := atomic.LoadInt32(relogins); n != 0 {
+			t.Errorf("%d relogins, want 0", n)
+		}
+	})
+
+	t.Run("session", func(t *testing.T) {
+		srv := newServer(t)
+		acc, relogins := login(t, srv)
+		events, states := startChat(t, acc)
+
+		srv.ExpireSessions()
+		waitState(t, states, steam.ChatConnecting)
+		waitState(t, states, steam.ChatOnline)
+		srv.DeliverMessage(friendIDs[0], botID, "hi")
+		waitMessage(t, events, friendIDs[0], "hi")
+		if n := atomic.LoadInt32(relogins); n != 1 {
+			t.Errorf("%d relogins, want 1", n)
+		}
+	})
+}
+
+func TestConcurrentRelogins(t *testing.T) {
+	srv := newServer(t)
+	acc, relogins := login(t, srv)
+
+	startChat(t, acc)
+
+	// Messages are in flight while the chat notices the expiry and logs on
+	// again.
+	srv.Inject(steamtest.Fault{Path: "/ISteamWebUserPresenceOAuth/Message/", Delay: 20 * time.Millisecond})
+	srv.ExpireSessions()
+	var wg sync.WaitGroup
+	run := func(name string, op func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := op(); err != nil {
+				t.Errorf("%s: %v", name, err)
+			}
+		}()
+	}
+	run("Broadcast", func() error {
+		return acc.Broadcast("hello")
+	})
+	run("Message", func() error {
+		for i := 0; i < 10; i++ {
+			if err := acc.Message(friendIDs[0], "hello"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	run("InviteToGroup", func() error {
+		return acc.InviteToGroup(groupID, friendIDs[0])
+	})
+	wg.Wait()
+
+	if n := atomic.LoadInt32(relogins); n != 1 {
+		t.Errorf("%d relogins, want 1", n)
+	}
+}
@@ -0,0 +1,56 @@
+package steam_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Acidic9/steam"
+	"github.com/Acidic9/steam/steamtest"
+)
+
+func TestSearchForID(t *testing.T) {
+	srv := steamtest.New()
+	defer srv.Close()
+	srv.AddPlayer(steamtest.Player{SteamID: 76561198132612090, VanityURL: "acidic9"})
+	// "bcd-fgh" is also a valid invite code, of another account.
+	srv.AddPlayer(steamtest.Player{SteamID: 76561198132612091, VanityURL: "bcd-fgh"})
+	client := srv.Client()
+
+	const want = steam.SteamID64(76561198132612090)
+	tests := []struct {
+		query  string
+		vanity bool // whether the query is resolved as a vanity URL name
+	}{
+		{"76561198132612090", false},
+		{"STEAM_0:0:86173181", false},
+		{"[U:1:172346362]", false},
+		{"https://steamcommunity.com/profiles/76561198132612090", false},
+		{"https://s.team/p/pgh-rqwp", false},
+		{"pgh-rqwp", true}, // no vanity URL of that name exists
+		{"SZY7F-WKCJ", false},
+		{"acidic9", true},
+	}
+	for _, test := range tests {
+		before := len(srv.Requests())
+		if got := client.SearchForID(test.query); got != want {
+			t.Errorf("SearchForID(%q) = %v, want %v", test.query, got, want)
+		}
+
+		resolved := false
+		for _, r := range srv.Requests()[before:] {
+			if strings.Contains(r.Path, "ResolveVanityURL") {
+				resolved = true
+			}
+		}
+		if resolved != test.vanity {
+			t.Errorf("SearchForID(%q) resolved a vanity URL: %v, want %v", test.query, resolved, test.vanity)
+		}
+	}
+
+	if got := client.SearchForID("bcd-fgh"); got != 76561198132612091 {
+		t.Errorf("SearchForID of a vanity URL name which is an invite code = %v, want 76561198132612091", got)
+	}
+	if got := client.SearchForID("nobody-here"); got != 0 {
+		t.Errorf("SearchForID of an unknown name = %v, want 0", got)
+	}
+}
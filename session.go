@@ -0,0 +1,372 @@
+package steam
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// sessionVersion is the version of the session blobs written by MarshalSession.
+const sessionVersion = 1
+
+// Errors returned when restoring a session.
+var (
+	// ErrNoSession is returned by a SessionStore which holds no session for
+	// the requested username.
+	ErrNoSession = errors.New("steam: no saved session")
+
+	// ErrSessionVersion is returned for a session blob written by an
+	// incompatible version of this package.
+	ErrSessionVersion = errors.New("steam: unsupported session version")
+
+	// ErrSessionKey is returned when an encrypted session blob cannot be
+	// decrypted with the given key, or a key is missing.
+	ErrSessionKey = errors.New("steam: wrong session key")
+)
+
+// Session is the state of a logged in Account, which can be saved and
+// restored later to avoid logging in again. It holds credentials and should be
+// stored as carefully as a password.
+type Session struct {
+	Username    string          `json:"username"`
+	SteamID     SteamID64       `json:"steamid"`
+	Umqid       string          `json:"umqid,omitempty"`
+	AccessToken string          `json:"access_token,omitempty"`
+	Cookies     []SessionCookie `json:"cookies"`
+	SavedAt     time.Time       `json:"saved_at"`
+}
+
+// SessionCookie is a cookie of a Session.
+type SessionCookie struct {
+	URL   string `json:"url"` // the URL the cookie was sent to
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// sessionURLs returns the URLs whose cookies make up the session of acc.
+func (acc *Account) sessionURLs() []string {
+	return []string{
+		acc.client().communityURL("", nil),
+		"https://store.steampowered.com/",
+		"https://help.steampowered.com/",
+	}
+}
+
+// Session returns the current session of acc.
+func (acc *Account) Session() *Session {
+	acc.mu.Lock()
+	s := &Session{
+		Username:    acc.Username,
+		SteamID:     acc.SteamID,
+		Umqid:       acc.Umqid,
+		AccessToken: acc.AccessToken,
+		SavedAt:     time.Now(),
+	}
+	httpClient := acc.HttpClient
+	acc.mu.Unlock()
+
+	if httpClient == nil || httpClient.Jar == nil {
+		return s
+	}
+	for _, rawurl := range acc.sessionURLs() {
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			continue
+		}
+		for _, cookie := range httpClient.Jar.Cookies(u) {
+			s.Cookies = append(s.Cookies, SessionCookie{URL: rawurl, Name: cookie.Name, Value: cookie.Value})
+		}
+	}
+	return s
+}
+
+// RestoreSession is a wrapper around DefaultClient.RestoreSession.
+func RestoreSession(s *Session) (*Account, error) {
+	return DefaultClient.RestoreSession(s)
+}
+
+// RestoreSessionContext is a wrapper around DefaultClient.RestoreSessionContext.
+func RestoreSessionContext(ctx context.Context, s *Session) (*Account, error) {
+	return DefaultClient.RestoreSessionContext(ctx, s)
+}
+
+// RestoreSession returns an Account using the session s and c for its
+// requests. ErrSessionExpired is returned along with the Account if the
+// session is no longer logged in; setting its Password then allows Relogin.
+// Other errors, such as a failed request, are also returned along with the
+// Account but say nothing about the session, which may still be valid.
+func (c *Client) RestoreSession(s *Session) (*Account, error) {
+	return c.RestoreSessionContext(context.Background(), s)
+}
+
+// RestoreSessionContext is like RestoreSession but its requests are bound to ctx.
+func (c *Client) RestoreSessionContext(ctx context.Context, s *Session) (*Account, error) {
+	acc := Account{
+		Username:    s.Username,
+		SteamID:     s.SteamID,
+		Umqid:       s.Umqid,
+		AccessToken: s.AccessToken,
+		Client:      c,
+	}
+	cookieJar, _ := cookiejar.New(nil)
+	acc.HttpClient = &http.Client{Jar: cookieJar, Timeout: time.Duration(120 * time.Second), Transport: c.httpClient().Transport}
+
+	for _, cookie := range s.Cookies {
+		u, err := url.Parse(cookie.URL)
+		if err != nil {
+			continue
+		}
+		cookieJar.SetCookies(u, []*http.Cookie{{Name: cookie.Name, Value: cookie.Value, Path: "/"}})
+	}
+
+	if err := acc.checkSession(ctx); err != nil {
+		return &acc, err
+	}
+	return &acc, nil
+}
+
+// sessionBlob is the versioned envelope written by MarshalSession. It holds
+// either the session in the clear or its encryption.
+type sessionBlob struct {
+	Version   int      `json:"version"`
+	Session   *Session `json:"session,omitempty"`
+	Nonce     []byte   `json:"nonce,omitempty"`
+	Encrypted []byte   `json:"encrypted,omitempty"`
+}
+
+// sessionCipher returns the AES-GCM cipher for key. Keys of any length are
+// accepted and hashed into an AES-256 key, but should be random secrets
+// rather than passwords.
+func sessionCipher(key []byte) (cipher.AEAD, error) {
+	sum := sha256.Sum256(key)
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// MarshalSession encodes s as a versioned blob. If key is not empty the
+// session is encrypted with AES-GCM.
+func MarshalSession(s *Session, key []byte) ([]byte, error) {
+	blob := sessionBlob{Version: sessionVersion}
+	if len(key) == 0 {
+		blob.Session = s
+		return json.Marshal(blob)
+	}
+
+	plain, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := sessionCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	blob.Nonce = make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, blob.Nonce); err != nil {
+		return nil, err
+	}
+	blob.Encrypted = aead.Seal(nil, blob.Nonce, plain, []byte(strconv.Itoa(blob.Version)))
+	return json.Marshal(blob)
+}
+
+// UnmarshalSession decodes a blob written by MarshalSession. key must be the
+// key the session was encrypted with, if any.
+func UnmarshalSession(data, key []byte) (*Session, error) {
+	var blob sessionBlob
+	if err := json.Unmarshal(data, &blob); err != nil {
+		return nil, err
+	}
+	if blob.Version != sessionVersion {
+		return nil, ErrSessionVersion
+	}
+
+	if blob.Encrypted == nil {
+		if blob.Session == nil {
+			return nil, errors.New("steam: empty session")
+		}
+		return blob.Session, nil
+	}
+
+	if len(key) == 0 {
+		return nil, ErrSessionKey
+	}
+	aead, err := sessionCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(blob.Nonce) != aead.NonceSize() {
+		return nil, ErrSessionKey
+	}
+	plain, err := aead.Open(nil, blob.Nonce, blob.Encrypted, []byte(strconv.Itoa(blob.Version)))
+	if err != nil {
+		return nil, ErrSessionKey
+	}
+
+	var s Session
+	if err := json.Unmarshal(plain, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SessionStore stores session blobs by username. Implementations backed by an
+// OS keyring or a secrets manager can be plugged in by implementing it.
+type SessionStore interface {
+	// Load returns the blob saved for username, or ErrNoSession.
+	Load(username string) ([]byte, error)
+	Save(username string, data []byte) error
+	Delete(username string) error
+}
+
+// SaveSession saves the session of acc in store, encrypted with key if it is
+// not empty.
+func (acc *Account) SaveSession(store SessionStore, key []byte) error {
+	data, err := MarshalSession(acc.Session(), key)
+	if err != nil {
+		return err
+	}
+	return store.Save(acc.Username, data)
+}
+
+// LoadSession is a wrapper around DefaultClient.LoadSession.
+func LoadSession(store SessionStore, username string, key []byte) (*Account, error) {
+	return DefaultClient.LoadSession(store, username, key)
+}
+
+// LoadSessionContext is a wrapper around DefaultClient.LoadSessionContext.
+func LoadSessionContext(ctx context.Context, store SessionStore, username string, key []byte) (*Account, error) {
+	return DefaultClient.LoadSessionContext(ctx, store, username, key)
+}
+
+// LoadSession restores the session of username saved in store with
+// SaveSession. See RestoreSession.
+func (c *Client) LoadSession(store SessionStore, username string, key []byte) (*Account, error) {
+	return c.LoadSessionContext(context.Background(), store, username, key)
+}
+
+// LoadSessionContext is like LoadSession but its requests are bound to ctx.
+func (c *Client) LoadSessionContext(ctx context.Context, store SessionStore, username string, key []byte) (*Account, error) {
+	data, err := store.Load(username)
+	if err != nil {
+		return nil, err
+	}
+	s, err := UnmarshalSession(data, key)
+	if err != nil {
+		return nil, err
+	}
+	return c.RestoreSessionContext(ctx, s)
+}
+
+// FileSessionStore is a SessionStore keeping each session in a file of Dir,
+// readable only by the current user.
+type FileSessionStore struct {
+	Dir string
+}
+
+// path returns the file holding the session of username.
+func (f FileSessionStore) path(username string) string {
+	// Usernames only hold letters, digits and underscores, but make sure a
+	// crafted one cannot escape Dir.
+	return filepath.Join(f.Dir, filepath.Base(filepath.Clean("/"+username))+".session")
+}
+
+// Load returns the blob saved for username.
+func (f FileSessionStore) Load(username string) ([]byte, error) {
+	data, err := ioutil.ReadFile(f.path(username))
+	if os.IsNotExist(err) {
+		return nil, ErrNoSession
+	}
+	return data, err
+}
+
+// Save saves data for username, creating Dir if needed.
+func (f FileSessionStore) Save(username string, data []byte) error {
+	if err := os.MkdirAll(f.Dir, 0700); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(f.Dir, ".session-")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.path(username))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Delete removes the session of username.
+func (f FileSessionStore) Delete(username string) error {
+	err := os.Remove(f.path(username))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// MemorySessionStore is a SessionStore keeping sessions in memory, for tests
+// and for processes which hand sessions to each other. The zero value is an
+// empty store.
+type MemorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string][]byte
+}
+
+// NewMemorySessionStore returns an empty MemorySessionStore.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string][]byte)}
+}
+
+// Load returns the blob saved for username.
+func (m *MemorySessionStore) Load(username string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data, ok := m.sessions[username]
+	if !ok {
+		return nil, ErrNoSession
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// Save saves data for username.
+func (m *MemorySessionStore) Save(username string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sessions == nil {
+		m.sessions = make(map[string][]byte)
+	}
+	m.sessions[username] = append([]byte(nil), data...)
+	return nil
+}
+
+// Delete removes the session of username.
+func (m *MemorySessionStore) Delete(username string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, username)
+	return nil
+}
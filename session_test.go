@@ -0,0 +1,109 @@
+package steam_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Acidic9/steam"
+	"github.com/Acidic9/steam/steamtest"
+)
+
+func TestMarshalSession(t *testing.T) {
+	s := &steam.Session{
+		Username:    "bot",
+		SteamID:     botID,
+		Umqid:       "12345",
+		AccessToken: "secret-token",
+		Cookies:     []steam.SessionCookie{{URL: "https://steamcommunity.com/", Name: "steamLoginSecure", Value: "secret-cookie"}},
+		SavedAt:     time.Unix(1700000000, 0).UTC(),
+	}
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	for _, key := range [][]byte{nil, key} {
+		data, err := steam.MarshalSession(s, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encrypted := strings.Contains(string(data), "secret"); encrypted == (key != nil) {
+			t.Errorf("with key %q the blob holds the secrets in the clear: %v", key, encrypted)
+		}
+		got, err := steam.UnmarshalSession(data, key)
+		if err != nil || !reflect.DeepEqual(got, s) {
+			t.Errorf("with key %q: UnmarshalSession = %+v, %v, want %+v", key, got, err, s)
+		}
+	}
+
+	data, _ := steam.MarshalSession(s, key)
+	if _, err := steam.UnmarshalSession(data, []byte("wrong")); !errors.Is(err, steam.ErrSessionKey) {
+		t.Errorf("UnmarshalSession with a wrong key: %v, want ErrSessionKey", err)
+	}
+	if _, err := steam.UnmarshalSession(data, nil); !errors.Is(err, steam.ErrSessionKey) {
+		t.Errorf("UnmarshalSession without a key: %v, want ErrSessionKey", err)
+	}
+
+	var blob map[string]interface{}
+	json.Unmarshal(data, &blob)
+	encrypted := []byte(blob["encrypted"].(string))
+	// Change a character of the base64 ciphertext.
+	if encrypted[4] == 'A' {
+		encrypted[4] = 'B'
+	} else {
+		encrypted[4] = 'A'
+	}
+	blob["encrypted"] = string(encrypted)
+	tampered, _ := json.Marshal(blob)
+	if _, err := steam.UnmarshalSession(tampered, key); !errors.Is(err, steam.ErrSessionKey) {
+		t.Errorf("UnmarshalSession of a tampered blob: %v, want ErrSessionKey", err)
+	}
+
+	blob["version"] = 2
+	future, _ := json.Marshal(blob)
+	if _, err := steam.UnmarshalSession(future, key); !errors.Is(err, steam.ErrSessionVersion) {
+		t.Errorf("UnmarshalSession of a newer blob: %v, want ErrSessionVersion", err)
+	}
+}
+
+func TestRestoreSession(t *testing.T) {
+	srv := newServer(t)
+	acc, _ := login(t, srv)
+	key := []byte("0123456789abcdef0123456789abcdef")
+	var store steam.MemorySessionStore
+	if err := acc.SaveSession(&store, key); err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+	client.Retry = steam.RetryPolicy{MaxRetries: -1}
+	restored, err := client.LoadSession(&store, "bot", key)
+	if err != nil {
+		t.Fatalf("LoadSession: %v", err)
+	}
+	if restored.SteamID != botID {
+		t.Errorf("restored SteamID = %v, want %v", restored.SteamID, botID)
+	}
+	if err := restored.Message(friendIDs[0], "restored"); err != nil {
+		t.Errorf("Message with the restored session: %v", err)
+	}
+
+	// A failed request says nothing about the session.
+	srv.Inject(steamtest.Fault{StatusCode: http.StatusServiceUnavailable, Times: 1})
+	_, err = client.LoadSession(&store, "bot", key)
+	var httpErr *steam.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable || errors.Is(err, steam.ErrSessionExpired) {
+		t.Errorf("LoadSession during an outage: %v, want the 503 *HTTPError", err)
+	}
+
+	srv.ExpireSessions()
+	if _, err := client.LoadSession(&store, "bot", key); !errors.Is(err, steam.ErrSessionExpired) {
+		t.Errorf("LoadSession after ExpireSessions: %v, want ErrSessionExpired", err)
+	}
+
+	if _, err := client.LoadSession(&store, "nobody", key); !errors.Is(err, steam.ErrNoSession) {
+		t.Errorf("LoadSession of an unknown user: %v, want ErrNoSession", err)
+	}
+}
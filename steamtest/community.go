@@ -0,0 +1,495 @@
+package steamtest
+
+import (
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"html"
+	"image"
+	"image/png"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Acidic9/steam"
+)
+
+// serveCommunity serves the community website paths used by the steam package.
+func (s *Server) serveCommunity(w http.ResponseWriter, r *http.Request, path string, segments []string) {
+	switch {
+	case path == "":
+		s.serveHome(w, r)
+	case path == "login/home":
+		s.writePage(w, r, `<form id="loginForm" action="`+s.URL+`/login/dologin/" method="POST"></form>`)
+	case path == "login/getrsakey":
+		s.serveGetRSAKey(w, r)
+	case path == "login/rendercaptcha":
+		s.serveRenderCaptcha(w, r)
+	case path == "login/dologin":
+		s.serveDoLogin(w, r)
+	case path == "login/transfer":
+		s.serveTransfer(w, r)
+	case path == "login/logout":
+		s.serveLogout(w, r)
+	case path == "chat":
+		s.serveChat(w, r)
+	case len(segments) == 3 && segments[0] == "profiles" && segments[2] == "friends":
+		s.serveFriendsPage(w, r, segments[1])
+	case len(segments) == 3 && segments[0] == "groups" && segments[2] == "memberslistxml":
+		s.serveMembersListXML(w, r, segments[1])
+	case path == "actions/GroupInvite":
+		s.serveGroupInvite(w, r)
+	case len(segments) == 3 && segments[0] == "games" && segments[2] == "selectAvatar":
+		s.serveSelectAvatar(w, r)
+	case path == "openid/login":
+		s.serveOpenID(w, r)
+	case path == "mobileconf/getlist":
+		s.serveConfirmationList(w, r)
+	case path == "mobileconf/ajaxop" || path == "mobileconf/multiajaxop":
+		s.serveConfirmationOp(w, r)
+	default:
+		writeErrorPage(w, http.StatusNotFound, "The page you requested could not be found.")
+	}
+}
+
+// writePage writes an HTML page with body, with the global variables and
+// login link of the real pages.
+func (s *Server) writePage(w http.ResponseWriter, r *http.Request, body string) {
+	sessionID := "0"
+	header := `<a class="global_action_link" href="` + s.URL + `/login/home/?goto=">login</a>`
+	if sess := s.session(r); sess != nil {
+		sessionID = sess.sessionID
+		header = `<a class="global_action_link" href="` + s.URL + `/profiles/` + strconv.FormatUint(uint64(sess.steamID), 10) + `/">profile</a>`
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+	w.Write([]byte(`<!DOCTYPE html>
+<html><head><script type="text/javascript">
+	g_sessionID = "` + sessionID + `";
+</script></head>
+<body><div id="global_header">` + header + `</div>
+` + body + `
+</body></html>`))
+}
+
+// serveHome serves the community front page.
+func (s *Server) serveHome(w http.ResponseWriter, r *http.Request) {
+	s.writePage(w, r, `<div class="community_home">Steam Community</div>`)
+}
+
+// serveGetRSAKey serves the RSA key used to encrypt the password.
+func (s *Server) serveGetRSAKey(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"success":       true,
+		"publickey_mod": s.key.N.Text(16),
+		"publickey_exp": big.NewInt(int64(s.key.E)).Text(16),
+		"timestamp":     s.rsaTimestamp,
+		"token_gid":     randomHex(8),
+	})
+}
+
+// loginResult is the response of dologin.
+type loginResult struct {
+	Success            bool                `json:"success"`
+	RequiresTwoFactor  bool                `json:"requires_twofactor"`
+	LoginComplete      bool                `json:"login_complete,omitempty"`
+	TransferURLs       []string            `json:"transfer_urls,omitempty"`
+	TransferParameters *transferParameters `json:"transfer_parameters,omitempty"`
+	Message            string              `json:"message,omitempty"`
+	EmailAuthNeeded    bool                `json:"emailauth_needed,omitempty"`
+	EmailDomain        string              `json:"emaildomain,omitempty"`
+	EmailSteamID       string              `json:"emailsteamid,omitempty"`
+	CaptchaNeeded      bool                `json:"captcha_needed"`
+	CaptchaGID         interface{}         `json:"captcha_gid"`
+}
+
+type transferParameters struct {
+	SteamID       string `json:"steamid"`
+	Token         string `json:"token"`
+	Auth          string `json:"auth"`
+	RememberLogin bool   `json:"remember_login"`
+	TokenSecure   string `json:"token_secure"`
+	WebCookie     string `json:"webcookie"`
+}
+
+// serveDoLogin checks the credentials and hands out transfer parameters.
+func (s *Server) serveDoLogin(w http.ResponseWriter, r *http.Request) {
+	failed := loginResult{
+		Message:    "The account name or password that you have entered is incorrect.",
+		CaptchaGID: -1,
+	}
+
+	if gid, ok := s.checkCaptcha(r.Form.Get("captchagid"), r.Form.Get("captcha_text")); !ok {
+		writeJSON(w, r, http.StatusOK, loginResult{
+			Message:       "Please verify your humanity by re-entering the characters in the captcha.",
+			CaptchaNeeded: true,
+			CaptchaGID:    gid,
+		})
+		return
+	}
+
+	s.mu.Lock()
+	user, ok := s.users[strings.ToLower(r.Form.Get("username"))]
+	s.mu.Unlock()
+	if !ok || r.Form.Get("rsatimestamp") != s.rsaTimestamp {
+		writeJSON(w, r, http.StatusOK, failed)
+		return
+	}
+
+	encrypted, err := base64.StdEncoding.DecodeString(r.Form.Get("password"))
+	if err != nil {
+		writeJSON(w, r, http.StatusOK, failed)
+		return
+	}
+	password, err := rsa.DecryptPKCS1v15(nil, s.key, encrypted)
+	if err != nil || string(password) != user.Password {
+		writeJSON(w, r, http.StatusOK, failed)
+		return
+	}
+
+	if user.SharedSecret != "" && !s.validAuthCode(user.SharedSecret, r.Form.Get("twofactorcode")) {
+		writeJSON(w, r, http.StatusOK, loginResult{RequiresTwoFactor: true, CaptchaGID: -1})
+		return
+	}
+
+	if user.EmailCode != "" && !s.knownMachine(r, user.SteamID) {
+		if r.Form.Get("emailauth") == "" || !strings.EqualFold(r.Form.Get("emailauth"), user.EmailCode) {
+			writeJSON(w, r, http.StatusOK, loginResult{
+				EmailAuthNeeded: true,
+				EmailDomain:     "example.com",
+				EmailSteamID:    strconv.FormatUint(uint64(user.SteamID), 10),
+				CaptchaGID:      -1,
+			})
+			return
+		}
+
+		machine := randomHex(20)
+		s.mu.Lock()
+		s.machines[user.SteamID] = machine
+		s.mu.Unlock()
+		http.SetCookie(w, &http.Cookie{Name: machineAuthCookie(user.SteamID), Value: machine, Path: "/", HttpOnly: true})
+	}
+
+	token := randomHex(20)
+	s.mu.Lock()
+	s.transfers[token] = user.SteamID
+	s.mu.Unlock()
+
+	writeJSON(w, r, http.StatusOK, loginResult{
+		Success:       true,
+		LoginComplete: true,
+		TransferURLs:  []string{s.URL + "/login/transfer"},
+		TransferParameters: &transferParameters{
+			SteamID:       strconv.FormatUint(uint64(user.SteamID), 10),
+			Token:         randomHex(20),
+			Auth:          randomHex(16),
+			RememberLogin: r.Form.Get("remember_login") == "true",
+			TokenSecure:   token,
+			WebCookie:     randomHex(20),
+		},
+		CaptchaGID: -1,
+	})
+}
+
+// RequireCaptcha makes every login solve a captcha whose answer is text, as
+// Steam does after too many failed logins. An empty text turns captchas off.
+func (s *Server) RequireCaptcha(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.captcha = text
+}
+
+// checkCaptcha reports whether text answers the captcha gid, if one is
+// required. Otherwise the gid of a new captcha is returned.
+func (s *Server) checkCaptcha(gid, text string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.captcha == "" {
+		return "", true
+	}
+	if s.captchas[gid] {
+		delete(s.captchas, gid)
+		if strings.EqualFold(text, s.captcha) {
+			return "", true
+		}
+	}
+
+	newGID := strconv.FormatUint(randomUint64()>>1, 10)
+	s.captchas[newGID] = true
+	return newGID, false
+}
+
+// serveRenderCaptcha serves the image of a captcha handed out by dologin.
+// The image is blank, as the answer is set with RequireCaptcha.
+func (s *Server) serveRenderCaptcha(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	ok := s.captchas[r.Form.Get("gid")]
+	s.mu.Unlock()
+
+	if !ok {
+		writeErrorPage(w, http.StatusNotFound, "")
+		return
+	}
+
+	img := image.NewGray(image.Rect(0, 0, 206, 40))
+	for i := range img.Pix {
+		img.Pix[i] = 0xEE
+	}
+	w.Header().Set("Content-Type", "image/png")
+	png.Encode(w, img)
+}
+
+// machineAuthCookie returns the name of the cookie which remembers a machine
+// that answered the email code of steamID.
+func machineAuthCookie(steamID steam.SteamID64) string {
+	return "steamMachineAuth" + strconv.FormatUint(uint64(steamID), 10)
+}
+
+// knownMachine reports whether r carries the steamMachineAuth cookie of the
+// last email code login of steamID.
+func (s *Server) knownMachine(r *http.Request, steamID steam.SteamID64) bool {
+	cookie, err := r.Cookie(machineAuthCookie(steamID))
+	if err != nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.machines[steamID] != "" && cookie.Value == s.machines[steamID]
+}
+
+// validAuthCode reports whether code is the Steam Guard mobile code of
+// sharedSecret for the current, previous or next period, as Steam allows
+// for some clock skew.
+func (s *Server) validAuthCode(sharedSecret, code string) bool {
+	if code == "" {
+		return false
+	}
+	now := s.Now()
+	for _, skew := range []time.Duration{0, -30 * time.Second, 30 * time.Second} {
+		if expected, err := authCode(sharedSecret, now.Add(skew)); err == nil && strings.EqualFold(code, expected) {
+			return true
+		}
+	}
+	return false
+}
+
+// authCode returns the Steam Guard mobile code of sharedSecret at t. It is
+// computed here rather than with steam.GenerateAuthCode so that the Server
+// checks the codes of the steam package instead of agreeing with them.
+func authCode(sharedSecret string, t time.Time) (string, error) {
+	secret, err := base64.StdEncoding.DecodeString(sharedSecret)
+	if err != nil {
+		return "", err
+	}
+
+	// HOTP (RFC 4226) of the number of 30 second periods since the epoch.
+	period := uint64(t.Unix() / 30)
+	counter := make([]byte, 8)
+	for i := range counter {
+		counter[i] = byte(period >> (56 - 8*uint(i)))
+	}
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+	offset := int(sum[19] & 0xF)
+	n := (uint32(sum[offset])&0x7F)<<24 | uint32(sum[offset+1])<<16 | uint32(sum[offset+2])<<8 | uint32(sum[offset+3])
+
+	// Steam renders it as 5 characters of its own alphabet, least
+	// significant first, instead of decimal digits.
+	const alphabet = "23456789BCDFGHJKMNPQRTVWXY"
+	var code strings.Builder
+	for i := 0; i < 5; i++ {
+		code.WriteByte(alphabet[n%26])
+		n /= 26
+	}
+	return code.String(), nil
+}
+
+// serveTransfer turns the transfer token of a successful login into a
+// session cookie.
+func (s *Server) serveTransfer(w http.ResponseWriter, r *http.Request) {
+	token := r.Form.Get("token_secure")
+
+	s.mu.Lock()
+	steamID, ok := s.transfers[token]
+	if ok {
+		delete(s.transfers, token)
+		s.sessions[token] = &session{
+			steamID:     steamID,
+			sessionID:   randomHex(12),
+			accessToken: randomHex(16),
+		}
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, r, http.StatusOK, map[string]interface{}{"result": int(steam.EResultInvalidParam)})
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: "steamLoginSecure", Value: token, Path: "/", HttpOnly: true})
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{"result": int(steam.EResultOK)})
+}
+
+// serveLogout ends the session if the sessionid parameter matches it.
+func (s *Server) serveLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie("steamLoginSecure"); err == nil {
+		s.mu.Lock()
+		if sess, ok := s.sessions[cookie.Value]; ok && sess.sessionID == r.Form.Get("sessionid") {
+			delete(s.sessions, cookie.Value)
+		}
+		s.mu.Unlock()
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: "steamLoginSecure", Value: "", Path: "/", MaxAge: -1})
+	http.Redirect(w, r, s.URL+"/", http.StatusFound)
+}
+
+// serveChat serves the web chat page, which holds the Web API access token.
+func (s *Server) serveChat(w http.ResponseWriter, r *http.Request) {
+	sess := s.session(r)
+	if sess == nil {
+		http.Redirect(w, r, s.URL+"/login/home/?goto=chat", http.StatusFound)
+		return
+	}
+
+	s.writePage(w, r, `<script type="text/javascript">
+	var g_WebAPI = new CWebAPI( '`+s.URL+`/', '`+s.URL+`/', "`+sess.accessToken+`" );
+</script>`)
+}
+
+// serveFriendsPage serves the friends page of a profile.
+func (s *Server) serveFriendsPage(w http.ResponseWriter, r *http.Request, id string) {
+	steamID, _ := strconv.ParseUint(id, 10, 64)
+
+	s.mu.Lock()
+	player, ok := s.players[steam.SteamID64(steamID)]
+	var friends []Friend
+	if ok {
+		friends = append(friends, player.Friends...)
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeErrorPage(w, http.StatusNotFound, "The specified profile could not be found.")
+		return
+	}
+
+	var body strings.Builder
+	body.WriteString(`<div id="memberList">`)
+	for _, friend := range friends {
+		id := strconv.FormatUint(uint64(friend.SteamID), 10)
+		body.WriteString("\n" + `<div class="friendBlock" data-steamid="` + id + `"><input class="friendCheckbox" type="checkbox" name="friends[` + id + `]"></div>`)
+	}
+	body.WriteString("\n</div>")
+	s.writePage(w, r, body.String())
+}
+
+// serveMembersListXML serves the XML members list of a group.
+func (s *Server) serveMembersListXML(w http.ResponseWriter, r *http.Request, name string) {
+	s.mu.Lock()
+	group, ok := s.groups[strings.ToLower(name)]
+	var members []steam.SteamID64
+	if ok {
+		members = append(members, group.Members...)
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/xml; charset=UTF-8")
+	if !ok {
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?><response><error><![CDATA[No group could be retrieved for the given URL.]]></error></response>`))
+		return
+	}
+
+	count := strconv.Itoa(len(members))
+	var body strings.Builder
+	body.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<memberList>
+<groupID64>` + strconv.FormatUint(uint64(group.ID), 10) + `</groupID64>
+<groupDetails>
+<groupName><![CDATA[` + html.EscapeString(group.Name) + `]]></groupName>
+<groupURL><![CDATA[` + html.EscapeString(group.Name) + `]]></groupURL>
+<memberCount>` + count + `</memberCount>
+</groupDetails>
+<memberCount>` + count + `</memberCount>
+<totalPages>1</totalPages>
+<currentPage>1</currentPage>
+<startingMember>0</startingMember>
+<members>
+`)
+	for _, member := range members {
+		body.WriteString("<steamID64>" + strconv.FormatUint(uint64(member), 10) + "</steamID64>\n")
+	}
+	body.WriteString("</members>\n</memberList>")
+	w.Write([]byte(body.String()))
+}
+
+// serveGroupInvite records the invites sent by a logged in user.
+func (s *Server) serveGroupInvite(w http.ResponseWriter, r *http.Request) {
+	sess := s.session(r)
+	if sess == nil {
+		http.Redirect(w, r, s.URL+"/login/home/?goto=actions%2FGroupInvite", http.StatusFound)
+		return
+	}
+	if r.Form.Get("sessionID") != sess.sessionID {
+		w.Write([]byte("null"))
+		return
+	}
+
+	groupID, _ := strconv.ParseUint(r.Form.Get("group"), 10, 64)
+	var invitees []string
+	if err := json.Unmarshal([]byte(r.Form.Get("invitee_list")), &invitees); err != nil {
+		writeJSON(w, r, http.StatusOK, map[string]interface{}{"results": "Invalid invitee list."})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found := false
+	for _, group := range s.groups {
+		if uint64(group.ID) == groupID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		writeJSON(w, r, http.StatusOK, map[string]interface{}{"results": "The group could not be found."})
+		return
+	}
+
+	for _, invitee := range invitees {
+		id, err := strconv.ParseUint(invitee, 10, 64)
+		if err != nil {
+			continue
+		}
+		s.invites = append(s.invites, Invite{From: sess.steamID, Group: steam.GroupID(groupID), Invitee: steam.SteamID64(id)})
+	}
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"results":   "OK",
+		"groupId":   strconv.FormatUint(groupID, 10),
+		"duplicate": false,
+	})
+}
+
+// serveSelectAvatar accepts the avatar changes of a logged in user.
+func (s *Server) serveSelectAvatar(w http.ResponseWriter, r *http.Request) {
+	sess := s.session(r)
+	if sess == nil {
+		http.Redirect(w, r, s.URL+"/login/home/?goto="+url.QueryEscape(strings.Trim(r.URL.Path, "/")), http.StatusFound)
+		return
+	}
+	if r.Method != "POST" || r.Form.Get("sessionid") != sess.sessionID {
+		writeErrorPage(w, http.StatusForbidden, "Invalid session.")
+		return
+	}
+
+	s.writePage(w, r, `<div class="profile_header">Your avatar has been updated.</div>`)
+}
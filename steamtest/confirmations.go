@@ -0,0 +1,162 @@
+package steamtest
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Acidic9/steam"
+)
+
+// Confirmation is a mobile confirmation waiting for a user of a Server.
+type Confirmation struct {
+	ID        uint64 // set by AddConfirmation if zero
+	Type      steam.ConfirmationType
+	CreatorID uint64 // the trade offer or market listing ID
+	Headline  string
+	Summary   []string
+
+	// Accepted and Cancelled tell how the confirmation was answered.
+	Accepted  bool
+	Cancelled bool
+
+	nonce   string
+	created time.Time
+}
+
+// AddConfirmation adds a confirmation for the user steamID, to be listed and
+// answered with the user's IdentitySecret.
+func (s *Server) AddConfirmation(steamID steam.SteamID64, c Confirmation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c.ID == 0 {
+		c.ID = randomUint64() >> 1
+	}
+	c.nonce = strconv.FormatUint(randomUint64()>>1, 10)
+	c.created = time.Now()
+	s.confs[steamID] = append(s.confs[steamID], &c)
+}
+
+// Confirmations returns the confirmations of the user steamID, answered or
+// not, oldest first.
+func (s *Server) Confirmations(steamID steam.SteamID64) []Confirmation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	confirmations := make([]Confirmation, 0, len(s.confs[steamID]))
+	for _, c := range s.confs[steamID] {
+		confirmations = append(confirmations, *c)
+	}
+	return confirmations
+}
+
+// confirmationUser returns the SteamID of the logged in user whose device
+// signed a confirmation request. ok is false, and the answer written, if the
+// request is not authenticated.
+func (s *Server) confirmationUser(w http.ResponseWriter, r *http.Request) (steamID steam.SteamID64, ok bool) {
+	sess := s.session(r)
+	if sess == nil || r.Form.Get("a") != strconv.FormatUint(uint64(sess.steamID), 10) {
+		writeJSON(w, r, http.StatusOK, map[string]interface{}{"success": false, "needauth": true})
+		return 0, false
+	}
+
+	s.mu.Lock()
+	var secret string
+	for _, user := range s.users {
+		if user.SteamID == sess.steamID {
+			secret = user.IdentitySecret
+		}
+	}
+	s.mu.Unlock()
+
+	unix, _ := strconv.ParseInt(r.Form.Get("t"), 10, 64)
+	t := time.Unix(unix, 0)
+	if d := s.Now().Sub(t); d > time.Minute || d < -time.Minute || secret == "" {
+		writeJSON(w, r, http.StatusOK, map[string]interface{}{"success": false, "message": "Invalid authenticator"})
+		return 0, false
+	}
+	key, err := steam.GenerateConfirmationKey(secret, t, r.Form.Get("tag"))
+	if err != nil || key != r.Form.Get("k") || r.Form.Get("p") == "" {
+		writeJSON(w, r, http.StatusOK, map[string]interface{}{"success": false, "message": "Invalid authenticator"})
+		return 0, false
+	}
+	return sess.steamID, true
+}
+
+// serveConfirmationList serves the pending confirmations of the user.
+func (s *Server) serveConfirmationList(w http.ResponseWriter, r *http.Request) {
+	steamID, ok := s.confirmationUser(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	conf := []map[string]interface{}{}
+	for _, c := range s.confs[steamID] {
+		if c.Accepted || c.Cancelled {
+			continue
+		}
+		conf = append(conf, map[string]interface{}{
+			"type":          int(c.Type),
+			"type_name":     c.Type.String(),
+			"id":            strconv.FormatUint(c.ID, 10),
+			"creator_id":    strconv.FormatUint(c.CreatorID, 10),
+			"nonce":         c.nonce,
+			"creation_time": c.created.Unix(),
+			"cancel":        "Cancel",
+			"accept":        "Confirm",
+			"icon":          "",
+			"multi":         false,
+			"headline":      c.Headline,
+			"summary":       append([]string{}, c.Summary...),
+		})
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{"success": true, "conf": conf})
+}
+
+// serveConfirmationOp accepts or cancels the confirmations given by the cid
+// and ck parameters, or their cid[] and ck[] lists.
+func (s *Server) serveConfirmationOp(w http.ResponseWriter, r *http.Request) {
+	steamID, ok := s.confirmationUser(w, r)
+	if !ok {
+		return
+	}
+
+	op := r.Form.Get("op")
+	ids, keys := r.Form["cid[]"], r.Form["ck[]"]
+	if r.Form.Get("cid") != "" {
+		ids, keys = []string{r.Form.Get("cid")}, []string{r.Form.Get("ck")}
+	}
+	if (op != "allow" && op != "cancel") || op != r.Form.Get("tag") || len(ids) == 0 || len(ids) != len(keys) {
+		writeJSON(w, r, http.StatusOK, map[string]interface{}{"success": false})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Every confirmation must be pending before any is answered.
+	var answered []*Confirmation
+	for i, id := range ids {
+		var found *Confirmation
+		for _, c := range s.confs[steamID] {
+			if strconv.FormatUint(c.ID, 10) == id && c.nonce == keys[i] && !c.Accepted && !c.Cancelled {
+				found = c
+				break
+			}
+		}
+		if found == nil {
+			writeJSON(w, r, http.StatusOK, map[string]interface{}{"success": false})
+			return
+		}
+		answered = append(answered, found)
+	}
+	for _, c := range answered {
+		c.Accepted = op == "allow"
+		c.Cancelled = op == "cancel"
+	}
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{"success": true})
+}
@@ -0,0 +1,179 @@
+package steamtest
+
+import (
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/Acidic9/steam"
+)
+
+// User is an account which can log into a Server.
+type User struct {
+	Username string
+	Password string
+	SteamID  steam.SteamID64
+
+	// SharedSecret, if set, is the base64 shared_secret of the user's Steam
+	// Guard mobile authenticator, whose codes are required to log in.
+	SharedSecret string
+
+	// EmailCode, if set, is the Steam Guard code emailed to the user, which
+	// is required to log in from a machine without the steamMachineAuth
+	// cookie set by a previous login with the code.
+	EmailCode string
+
+	// IdentitySecret, if set, is the base64 identity_secret of the user's
+	// Steam Guard mobile authenticator, which signs confirmation requests.
+	IdentitySecret string
+}
+
+// Player is a community profile known to a Server.
+type Player struct {
+	SteamID        steam.SteamID64
+	PersonaName    string
+	RealName       string
+	VanityURL      string // the custom URL name resolved by ResolveVanityURL, if any
+	PersonaState   int
+	Private        bool // the profile and its friends list are private
+	PrimaryGroupID steam.GroupID
+	TimeCreated    int64
+	GameID         int
+	GameExtraInfo  string
+	CountryCode    string
+	Friends        []Friend
+}
+
+// Friend is an entry of the friends list of a Player.
+type Friend struct {
+	SteamID     steam.SteamID64
+	FriendSince int64
+}
+
+// Group is a community group known to a Server.
+type Group struct {
+	ID      steam.GroupID
+	Name    string // the custom URL name of the group
+	Members []steam.SteamID64
+}
+
+// App is an app known to a Server.
+type App struct {
+	AppID   int
+	Name    string
+	Players int // the number of players reported by GetNumberOfCurrentPlayers
+}
+
+// Message is a chat message sent by a client through a Server.
+type Message struct {
+	From steam.SteamID64
+	To   steam.SteamID64
+	Type string // eg. "saytext" or "typing"
+	Text string
+}
+
+// Ticket is an auth session ticket a game client got from Steam.
+type Ticket struct {
+	AppID           int
+	Ticket          []byte
+	SteamID         steam.SteamID64
+	OwnerSteamID    steam.SteamID64 // the license owner; SteamID if zero
+	VACBanned       bool
+	PublisherBanned bool
+}
+
+// Invite is a group invite sent by a client through a Server.
+type Invite struct {
+	From    steam.SteamID64
+	Group   steam.GroupID
+	Invitee steam.SteamID64
+}
+
+// AddUser adds or replaces a user. A Player is added for the user's SteamID
+// if there is none.
+func (s *Server) AddUser(u User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users[strings.ToLower(u.Username)] = &u
+	if _, ok := s.players[u.SteamID]; !ok {
+		s.players[u.SteamID] = &Player{SteamID: u.SteamID, PersonaName: u.Username}
+	}
+}
+
+// AddPlayer adds or replaces a player.
+func (s *Server) AddPlayer(p Player) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.players[p.SteamID] = &p
+}
+
+// AddGroup adds or replaces a group.
+func (s *Server) AddGroup(g Group) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.groups[strings.ToLower(g.Name)] = &g
+}
+
+// AddTicket adds a ticket, which can be authenticated until it is cancelled.
+func (s *Server) AddTicket(t Ticket) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tickets[hex.EncodeToString(t.Ticket)] = &t
+}
+
+// CancelTicket cancels a ticket, as the client does when the game session
+// ends.
+func (s *Server) CancelTicket(ticket []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tickets, hex.EncodeToString(ticket))
+}
+
+// AddApp adds an app.
+func (s *Server) AddApp(a App) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apps = append(s.apps, a)
+}
+
+// ChatEvent is a web chat event delivered to a user by a Server.
+type ChatEvent struct {
+	Type         string // the poll message type, eg. "saytext" or "personastate"
+	From         steam.SteamID64
+	Text         string
+	PersonaName  string
+	PersonaState int
+	StatusFlags  int
+}
+
+// DeliverMessage makes from send text to the user to, to be received by the
+// user's next Poll.
+func (s *Server) DeliverMessage(from, to steam.SteamID64, text string) {
+	s.DeliverEvent(to, ChatEvent{Type: "saytext", From: from, Text: text})
+}
+
+// DeliverEvent delivers e to the user to, to be received by the user's next
+// Poll.
+func (s *Server) DeliverEvent(to steam.SteamID64, e ChatEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inbox[to] = append(s.inbox[to], inboxMessage{ChatEvent: e, time: time.Now()})
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// Messages returns the messages sent by clients so far, oldest first.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.sent...)
+}
+
+// Invites returns the group invites sent by clients so far, oldest first.
+func (s *Server) Invites() []Invite {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Invite(nil), s.invites...)
+}
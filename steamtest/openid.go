@@ -0,0 +1,100 @@
+package steamtest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Acidic9/steam"
+)
+
+// openIDNamespace is the namespace of OpenID 2.0 messages.
+const openIDNamespace = "http://specs.openid.net/auth/2.0"
+
+// openIDSigned lists the fields signed in the assertions of a Server, as
+// Steam does.
+const openIDSigned = "signed,op_endpoint,claimed_id,identity,return_to,response_nonce,assoc_handle"
+
+// SignInOpenID emulates the user steamID signing in on the OpenID login page
+// at loginURL, such as the one returned by steam.OpenID.LoginURL, and returns
+// the callback URL Steam redirects the user to.
+func (s *Server) SignInOpenID(loginURL string, steamID steam.SteamID64) (string, error) {
+	u, err := url.Parse(loginURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	if query.Get("openid.mode") != "checkid_setup" || query.Get("openid.ns") != openIDNamespace {
+		return "", errors.New("steamtest: not an OpenID login URL")
+	}
+	returnTo, err := url.Parse(query.Get("openid.return_to"))
+	if err != nil || !returnTo.IsAbs() {
+		return "", errors.New("steamtest: invalid openid.return_to")
+	}
+
+	claimedID := s.URL + "/openid/id/" + strconv.FormatUint(uint64(steamID), 10)
+	assertion := url.Values{
+		"openid.ns":             {openIDNamespace},
+		"openid.mode":           {"id_res"},
+		"openid.op_endpoint":    {s.URL + "/openid/login"},
+		"openid.claimed_id":     {claimedID},
+		"openid.identity":       {claimedID},
+		"openid.return_to":      {returnTo.String()},
+		"openid.response_nonce": {time.Now().UTC().Format("2006-01-02T15:04:05Z") + randomHex(8)},
+		"openid.assoc_handle":   {"1234567890"},
+		"openid.signed":         {openIDSigned},
+	}
+	assertion.Set("openid.sig", s.openIDSignature(assertion))
+
+	s.mu.Lock()
+	s.nonces[assertion.Get("openid.response_nonce")] = true
+	s.mu.Unlock()
+
+	callback := *returnTo
+	callbackQuery := returnTo.Query()
+	for key, values := range assertion {
+		callbackQuery[key] = values
+	}
+	callback.RawQuery = callbackQuery.Encode()
+	return callback.String(), nil
+}
+
+// openIDSignature returns the signature of the signed fields of an assertion.
+func (s *Server) openIDSignature(assertion url.Values) string {
+	var message strings.Builder
+	for _, field := range strings.Split(assertion.Get("openid.signed"), ",") {
+		message.WriteString(field + ":" + assertion.Get("openid."+field) + "\n")
+	}
+	mac := hmac.New(sha256.New, s.openIDKey)
+	mac.Write([]byte(message.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// serveOpenID answers the check_authentication requests of relying parties.
+// Each assertion is confirmed once.
+func (s *Server) serveOpenID(w http.ResponseWriter, r *http.Request) {
+	if r.Form.Get("openid.mode") != "check_authentication" {
+		writeErrorPage(w, http.StatusBadRequest, "Sign in with SignInOpenID.")
+		return
+	}
+
+	valid := r.Form.Get("openid.signed") == openIDSigned &&
+		hmac.Equal([]byte(r.Form.Get("openid.sig")), []byte(s.openIDSignature(r.Form)))
+
+	nonce := r.Form.Get("openid.response_nonce")
+	s.mu.Lock()
+	if !s.nonces[nonce] {
+		valid = false
+	}
+	delete(s.nonces, nonce)
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte("ns:" + openIDNamespace + "\nis_valid:" + strconv.FormatBool(valid) + "\n"))
+}
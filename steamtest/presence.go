@@ -0,0 +1,179 @@
+package steamtest
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Acidic9/steam"
+)
+
+// notLoggedOn is the answer of the presence methods to an unknown access
+// token or umqid.
+var notLoggedOn = map[string]interface{}{"error": "Not Logged On"}
+
+// sessionByToken returns the session holding an access token.
+// s.mu must be held.
+func (s *Server) sessionByToken(accessToken string) *session {
+	if accessToken == "" {
+		return nil
+	}
+	for _, sess := range s.sessions {
+		if sess.accessToken == accessToken {
+			return sess
+		}
+	}
+	return nil
+}
+
+// serveLogon starts a chat session for an access token. A session which
+// names the umqid of an earlier one resumes it.
+func (s *Server) serveLogon(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	sess := s.sessionByToken(r.Form.Get("access_token"))
+	var last int
+	if sess != nil {
+		if umqid := r.Form.Get("umqid"); umqid != "" {
+			sess.umqid = umqid
+		} else if sess.umqid == "" {
+			sess.umqid = strconv.FormatUint(randomUint64(), 10)
+		}
+		last = len(s.inbox[sess.steamID])
+	}
+	s.mu.Unlock()
+
+	if sess == nil {
+		writeJSON(w, r, http.StatusOK, notLoggedOn)
+		return
+	}
+
+	now := time.Now()
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"steamid":       strconv.FormatUint(uint64(sess.steamID), 10),
+		"error":         "OK",
+		"umqid":         sess.umqid,
+		"timestamp":     now.UnixNano() / int64(time.Millisecond),
+		"utc_timestamp": now.Unix(),
+		"message":       last,
+		"push":          0,
+	})
+}
+
+// servePoll answers with the messages received after the message parameter,
+// waiting for one for up to sectimeout seconds (capped by PollTimeout).
+func (s *Server) servePoll(w http.ResponseWriter, r *http.Request) {
+	pollID, _ := strconv.ParseInt(r.Form.Get("pollid"), 10, 64)
+	cursor, _ := strconv.Atoi(r.Form.Get("message"))
+	secTimeout, _ := strconv.Atoi(r.Form.Get("sectimeout"))
+	timeout := time.Duration(secTimeout) * time.Second
+	if timeout <= 0 || timeout > s.PollTimeout {
+		timeout = s.PollTimeout
+	}
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		s.mu.Lock()
+		sess := s.sessionByUmqid(r.Form.Get("umqid"), r.Form.Get("access_token"))
+		var inbox []inboxMessage
+		if sess != nil {
+			inbox = s.inbox[sess.steamID]
+		}
+		notify := s.notify
+		s.mu.Unlock()
+
+		if sess == nil {
+			writeJSON(w, r, http.StatusOK, map[string]interface{}{"pollid": pollID, "error": "Not Logged On"})
+			return
+		}
+
+		if cursor < 0 || cursor > len(inbox) {
+			cursor = len(inbox)
+		}
+		if cursor < len(inbox) {
+			s.writePollMessages(w, r, pollID, cursor, inbox)
+			return
+		}
+
+		select {
+		case <-notify:
+		case <-deadline.C:
+			writeJSON(w, r, http.StatusOK, map[string]interface{}{
+				"pollid":     pollID,
+				"sectimeout": secTimeout,
+				"error":      "Timeout",
+			})
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writePollMessages writes the messages of inbox after cursor.
+func (s *Server) writePollMessages(w http.ResponseWriter, r *http.Request, pollID int64, cursor int, inbox []inboxMessage) {
+	messages := []map[string]interface{}{}
+	for _, message := range inbox[cursor:] {
+		m := map[string]interface{}{
+			"type":           message.Type,
+			"timestamp":      message.time.UnixNano() / int64(time.Millisecond),
+			"utc_timestamp":  message.time.Unix(),
+			"accountid_from": uint32(message.From),
+		}
+		if message.Text != "" {
+			m["text"] = message.Text
+		}
+		if message.Type == "personastate" || message.Type == "personarelationship" {
+			m["persona_state"] = message.PersonaState
+		}
+		if message.Type == "personastate" {
+			m["persona_name"] = message.PersonaName
+			m["status_flags"] = message.StatusFlags
+		}
+		messages = append(messages, m)
+	}
+
+	now := time.Now()
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"pollid":        pollID,
+		"messages":      messages,
+		"messagelast":   len(inbox),
+		"timestamp":     now.UnixNano() / int64(time.Millisecond),
+		"utc_timestamp": now.Unix(),
+		"messagebase":   cursor,
+		"error":         "OK",
+	})
+}
+
+// sessionByUmqid returns the session holding both a umqid and an access token.
+// s.mu must be held.
+func (s *Server) sessionByUmqid(umqid, accessToken string) *session {
+	sess := s.sessionByToken(accessToken)
+	if sess == nil || umqid == "" || sess.umqid != umqid {
+		return nil
+	}
+	return sess
+}
+
+// serveMessage records a message sent by a client.
+func (s *Server) serveMessage(w http.ResponseWriter, r *http.Request) {
+	to, _ := strconv.ParseUint(r.Form.Get("steamid_dst"), 10, 64)
+
+	s.mu.Lock()
+	sess := s.sessionByUmqid(r.Form.Get("umqid"), r.Form.Get("access_token"))
+	if sess != nil {
+		s.sent = append(s.sent, Message{
+			From: sess.steamID,
+			To:   steam.SteamID64(to),
+			Type: r.Form.Get("type"),
+			Text: r.Form.Get("text"),
+		})
+	}
+	s.mu.Unlock()
+
+	if sess == nil {
+		writeJSON(w, r, http.StatusOK, notLoggedOn)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{"error": "OK"})
+}
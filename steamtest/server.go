@@ -0,0 +1,337 @@
+// Package steamtest provides a fake Steam server for testing code which uses
+// the steam package without talking to the real Steam hosts.
+//
+// A Server emulates both the Web API and the community website on a single
+// local address. Its users, profiles, groups and apps are set up with the Add
+// methods, and faults such as error statuses or slow responses can be injected
+// for any path:
+//
+//	srv := steamtest.New()
+//	defer srv.Close()
+//
+//	srv.AddUser(steamtest.User{Username: "bot", Password: "hunter2", SteamID: 76561198132612090})
+//	srv.Inject(steamtest.Fault{Path: "/ISteamUser/", StatusCode: 503, Times: 1})
+//
+//	acc, err := srv.Client().Login("bot", "hunter2")
+package steamtest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Acidic9/steam"
+)
+
+// DefaultApiKey is the Web API key accepted by a new Server.
+const DefaultApiKey = "STEAMTESTKEY"
+
+// DefaultPublisherKey is the publisher key accepted by a new Server.
+const DefaultPublisherKey = "STEAMTESTPUBLISHERKEY"
+
+// Server is a fake Steam server. It is safe for concurrent use.
+type Server struct {
+	// URL is the base URL of the server, eg. "http://127.0.0.1:41234".
+	URL string
+
+	// ApiKey is the only Web API key accepted by the methods which need one.
+	// If empty, every key is accepted. It must not be changed while requests
+	// are being served.
+	ApiKey string
+
+	// PublisherKey is the only key accepted by the methods which need a
+	// publisher key. It must not be changed while requests are being served.
+	PublisherKey string
+
+	// PollTimeout caps how long a Poll request waits for a message before
+	// answering with a timeout, so that tests do not wait for the sectimeout
+	// asked for by the client. It must not be changed while requests are
+	// being served.
+	PollTimeout time.Duration
+
+	// TimeOffset is added to the local time to get the server time, to
+	// emulate a client whose clock is off. It must not be changed while
+	// requests are being served.
+	TimeOffset time.Duration
+
+	server       *httptest.Server
+	key          *rsa.PrivateKey
+	rsaTimestamp string
+
+	mu        sync.Mutex
+	users     map[string]*User // by lower case username
+	players   map[steam.SteamID64]*Player
+	groups    map[string]*Group // by lower case name
+	apps      []App
+	transfers map[string]steam.SteamID64 // pending transfer tokens
+	machines  map[steam.SteamID64]string // steamMachineAuth cookies
+	captcha   string                     // the answer to every captcha; empty when logins need none
+	captchas  map[string]bool            // the captcha gids handed out
+	sessions  map[string]*session        // by steamLoginSecure cookie
+	inbox     map[steam.SteamID64][]inboxMessage
+	confs     map[steam.SteamID64][]*Confirmation // mobile confirmations by owner
+	tickets   map[string]*Ticket                  // by hex ticket
+	openIDKey []byte                              // signs OpenID assertions
+	nonces    map[string]bool                     // OpenID nonces not checked yet
+	sent      []Message
+	invites   []Invite
+	faults    []*Fault
+	requests  []Request
+	notify    chan struct{} // closed and replaced when a message is delivered
+}
+
+// session is a logged in community session.
+type session struct {
+	steamID     steam.SteamID64
+	sessionID   string
+	accessToken string
+	umqid       string
+}
+
+// inboxMessage is a message waiting to be polled by its recipient.
+type inboxMessage struct {
+	ChatEvent
+	time time.Time
+}
+
+// Request is a request received by a Server.
+type Request struct {
+	Method string
+	Path   string
+	Form   url.Values // the query and body parameters
+}
+
+// Fault makes a Server misbehave on the requests matching Path.
+type Fault struct {
+	Path       string        // the prefix of the request paths affected, eg. "/ISteamUser/"; empty matches every path
+	StatusCode int           // the status to answer with; 0 serves the request normally after Delay
+	Body       string        // the body sent with StatusCode
+	Header     http.Header   // headers sent with StatusCode, eg. Retry-After
+	Delay      time.Duration // how long to wait before answering
+	Times      int           // the number of requests affected; 0 affects every request until ClearFaults
+}
+
+// New starts and returns a Server. The caller should call Close when finished.
+func New() *Server {
+	// A 1024 bit key keeps New fast; the client accepts any size.
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		panic("steamtest: failed to generate RSA key: " + err.Error())
+	}
+
+	s := &Server{
+		ApiKey:       DefaultApiKey,
+		PublisherKey: DefaultPublisherKey,
+		PollTimeout:  time.Second,
+		key:          key,
+		rsaTimestamp: "1000000000",
+		users:        make(map[string]*User),
+		players:      make(map[steam.SteamID64]*Player),
+		groups:       make(map[string]*Group),
+		transfers:    make(map[string]steam.SteamID64),
+		machines:     make(map[steam.SteamID64]string),
+		captchas:     make(map[string]bool),
+		sessions:     make(map[string]*session),
+		inbox:        make(map[steam.SteamID64][]inboxMessage),
+		confs:        make(map[steam.SteamID64][]*Confirmation),
+		tickets:      make(map[string]*Ticket),
+		openIDKey:    []byte(randomHex(32)),
+		nonces:       make(map[string]bool),
+		notify:       make(chan struct{}),
+	}
+	s.server = httptest.NewServer(s)
+	s.URL = s.server.URL
+	return s
+}
+
+// Close shuts down the server and blocks until all outstanding requests have
+// completed.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client returns a steam.Client which sends all of its requests to s. It uses
+// s.ApiKey, has no rate limit and retries quickly, so that faults injected
+// with Times are recovered from without slowing tests down.
+func (s *Server) Client() *steam.Client {
+	return &steam.Client{
+		HttpClient:       s.server.Client(),
+		ApiBaseURL:       s.URL,
+		CommunityBaseURL: s.URL,
+		PartnerBaseURL:   s.URL,
+		ApiKey:           s.ApiKey,
+		Limiter:          steam.NewLimiter(steam.RateLimit{}),
+		Retry: steam.RetryPolicy{
+			MaxRetries: 2,
+			MinBackoff: time.Millisecond,
+			MaxBackoff: 10 * time.Millisecond,
+		},
+	}
+}
+
+// Inject adds a fault. Faults are matched in the order they were added.
+func (s *Server) Inject(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes every fault.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// Requests returns the requests received so far, oldest first.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Now returns the server time.
+func (s *Server) Now() time.Time {
+	return time.Now().Add(s.TimeOffset)
+}
+
+// ExpireSessions logs out every session, as Steam does from time to time.
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = make(map[string]*session)
+}
+
+// ExpireChatSessions logs every session out of the web chat, as Steam does
+// with idle chat sessions, while leaving them logged into the community.
+func (s *Server) ExpireChatSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sess := range s.sessions {
+		sess.umqid = ""
+	}
+}
+
+// ServeHTTP records r, applies the first matching fault and serves r.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Form: r.Form})
+	fault := s.takeFault(r.URL.Path)
+	s.mu.Unlock()
+
+	if fault != nil {
+		if fault.Delay > 0 {
+			select {
+			case <-time.After(fault.Delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if fault.StatusCode != 0 {
+			for key, values := range fault.Header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(fault.StatusCode)
+			w.Write([]byte(fault.Body))
+			return
+		}
+	}
+
+	path := strings.Trim(r.URL.Path, "/")
+	segments := strings.Split(path, "/")
+	if len(segments) == 3 && strings.HasPrefix(segments[0], "I") {
+		s.serveWebAPI(w, r, segments[0]+"/"+segments[1])
+		return
+	}
+	s.serveCommunity(w, r, path, segments)
+}
+
+// takeFault returns the fault to apply to a request for path, if any.
+// s.mu must be held.
+func (s *Server) takeFault(path string) *Fault {
+	for i, f := range s.faults {
+		if !strings.HasPrefix(path, f.Path) {
+			continue
+		}
+		fault := *f
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		return &fault
+	}
+	return nil
+}
+
+// session returns the session of the cookies sent with r.
+func (s *Server) session(r *http.Request) *session {
+	cookie, err := r.Cookie("steamLoginSecure")
+	if err != nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions[cookie.Value]
+}
+
+// writeJSON writes v as JSON, wrapped in a call to the jsonp parameter of r
+// if it has one.
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	content, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if callback := r.Form.Get("jsonp"); callback != "" {
+		w.Header().Set("Content-Type", "text/javascript; charset=UTF-8")
+		w.WriteHeader(status)
+		w.Write([]byte(callback + "("))
+		w.Write(content)
+		w.Write([]byte(")"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	w.Write(content)
+}
+
+// writeErrorPage writes an HTML error page like the ones sent by Steam.
+func writeErrorPage(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+	w.WriteHeader(status)
+	text := http.StatusText(status)
+	w.Write([]byte("<html><head><title>" + text + "</title></head><body><h1>" + text + "</h1>" + message + "</body></html>"))
+}
+
+// randomUint64 returns a random number.
+func randomUint64() uint64 {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("steamtest: " + err.Error())
+	}
+	return binary.BigEndian.Uint64(b[:])
+}
+
+// randomHex returns n random bytes encoded as hex.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic("steamtest: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,188 @@
+package steamtest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Acidic9/steam"
+	"github.com/Acidic9/steam/steamtest"
+)
+
+const (
+	botID    = steam.SteamID64(76561198000000001)
+	friendID = steam.SteamID64(76561198000000002)
+)
+
+// newServer returns a Server with a single user, "bot", and a Client of it
+// which does not retry.
+func newServer(t *testing.T) (*steamtest.Server, *steam.Client) {
+	srv := steamtest.New()
+	t.Cleanup(srv.Close)
+	srv.PollTimeout = 50 * time.Millisecond
+	srv.AddUser(steamtest.User{Username: "bot", Password: "hunter2", SteamID: botID})
+	srv.AddApp(steamtest.App{AppID: 440, Name: "Team Fortress 2", Players: 50000})
+
+	client := srv.Client()
+	client.Retry = steam.RetryPolicy{MaxRetries: -1}
+	return srv, client
+}
+
+func TestFaultTimes(t *testing.T) {
+	srv, client := newServer(t)
+	srv.Inject(steamtest.Fault{Path: "/ISteamUserStats/", StatusCode: http.StatusServiceUnavailable, Times: 2})
+
+	for i := 0; i < 2; i++ {
+		_, err := client.GetNumberOfCurrentPlayers(440)
+		var httpErr *steam.HTTPError
+		if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("request %d: error = %v, want a 503 *HTTPError", i, err)
+		}
+	}
+	players, err := client.GetNumberOfCurrentPlayers(440)
+	if err != nil || players != 50000 {
+		t.Fatalf("after the fault: %d, %v", players, err)
+	}
+
+	if n := len(srv.Requests()); n != 3 {
+		t.Errorf("%d requests recorded, want 3", n)
+	}
+	for _, r := range srv.Requests() {
+		if r.Path != "/ISteamUserStats/GetNumberOfCurrentPlayers/v1" || r.Form.Get("appid") != "440" {
+			t.Errorf("unexpected request %+v", r)
+		}
+	}
+}
+
+func TestFaultPathAndClear(t *testing.T) {
+	srv, client := newServer(t)
+	srv.Inject(steamtest.Fault{Path: "/ISteamApps/", StatusCode: http.StatusInternalServerError})
+
+	if _, err := client.GetNumberOfCurrentPlayers(440); err != nil {
+		t.Errorf("a fault of another path applied: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetAppList(); err == nil {
+			t.Fatal("a fault without Times stopped applying")
+		}
+	}
+	srv.ClearFaults()
+	if _, err := client.GetAppList(); err != nil {
+		t.Errorf("after ClearFaults: %v", err)
+	}
+}
+
+func TestFaultDelay(t *testing.T) {
+	srv, client := newServer(t)
+	srv.Inject(steamtest.Fault{Delay: time.Second, Times: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetNumberOfCurrentPlayersContext(ctx, 440); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestApiKey(t *testing.T) {
+	srv, client := newServer(t)
+	srv.AddPlayer(steamtest.Player{SteamID: friendID, PersonaName: "friend"})
+
+	client.ApiKey = "wrong"
+	if _, err := client.GetPlayerSummaries(friendID); !errors.Is(err, steam.ErrUnauthorized) {
+		t.Errorf("error with a wrong key = %v, want ErrUnauthorized", err)
+	}
+	client.ApiKey = steamtest.DefaultApiKey
+	summary, err := client.GetPlayerSummaries(friendID)
+	if err != nil || summary.DisplayName != "friend" {
+		t.Errorf("GetPlayerSummaries = %+v, %v", summary, err)
+	}
+}
+
+func TestLoginAndExpireSessions(t *testing.T) {
+	srv, client := newServer(t)
+
+	if _, err := client.Login("bot", "wrong"); !errors.Is(err, steam.ErrInvalidCredentials) {
+		t.Errorf("login with a wrong password: %v, want ErrInvalidCredentials", err)
+	}
+
+	acc, err := client.Login("bot", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.SteamID != botID {
+		t.Errorf("SteamID = %v, want %v", acc.SteamID, botID)
+	}
+
+	// Without a password the Account cannot log in again, so the expiry is
+	// reported.
+	acc.Password = ""
+	srv.ExpireSessions()
+	if err := acc.Message(friendID, "hello"); !errors.Is(err, steam.ErrSessionExpired) {
+		t.Errorf("Message after ExpireSessions: %v, want ErrSessionExpired", err)
+	}
+	if n := len(srv.Messages()); n != 0 {
+		t.Errorf("%d messages recorded after the session expired", n)
+	}
+}
+
+func TestMessagesAndDelivery(t *testing.T) {
+	srv, client := newServer(t)
+	acc, err := client.Login("bot", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := acc.Message(friendID, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	want := steamtest.Message{From: botID, To: friendID, Type: "saytext", Text: "hello"}
+	if messages := srv.Messages(); len(messages) != 1 || messages[0] != want {
+		t.Errorf("Messages() = %+v, want [%+v]", messages, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	received := make(chan steam.ChatEvent, 10)
+	online := make(chan struct{}, 1)
+	chat := acc.Chat(steam.ChatHandlerFunc(func(e steam.ChatEvent) {
+		received <- e
+	}))
+	chat.OnState = func(state steam.ChatState, err error) {
+		if state == steam.ChatOnline {
+			select {
+			case online <- struct{}{}:
+			default:
+			}
+		}
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- chat.Run(ctx)
+	}()
+	// Events delivered before the logon are not received.
+	<-online
+
+	srv.DeliverEvent(botID, steamtest.ChatEvent{Type: "personastate", From: friendID, PersonaName: "friend", PersonaState: 1})
+	srv.DeliverMessage(friendID, botID, "hi")
+
+	for _, want := range []steam.ChatEvent{
+		{Type: steam.ChatPersonaState, From: friendID, PersonaName: "friend", PersonaState: 1},
+		{Type: steam.ChatMessage, From: friendID, Text: "hi"},
+	} {
+		select {
+		case e := <-received:
+			if e.Type != want.Type || e.From != want.From || e.Text != want.Text || e.PersonaName != want.PersonaName || e.PersonaState != want.PersonaState {
+				t.Errorf("received %+v, want %+v", e, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%v not received", want.Type)
+		}
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run returned %v, want context.Canceled", err)
+	}
+}
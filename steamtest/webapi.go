@@ -0,0 +1,290 @@
+package steamtest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Acidic9/steam"
+)
+
+// serveWebAPI serves the Web API methods used by the steam package. method is
+// the interface and method name, eg. "ISteamUser/GetPlayerSummaries".
+func (s *Server) serveWebAPI(w http.ResponseWriter, r *http.Request, method string) {
+	switch method {
+	case "ISteamUser/GetPlayerSummaries":
+		if s.checkKey(w, r) {
+			s.serveGetPlayerSummaries(w, r)
+		}
+	case "ISteamUser/GetFriendList":
+		if s.checkKey(w, r) {
+			s.serveGetFriendList(w, r)
+		}
+	case "ISteamUser/ResolveVanityURL":
+		if s.checkKey(w, r) {
+			s.serveResolveVanityURL(w, r)
+		}
+	case "ISteamApps/GetAppList":
+		s.serveGetAppList(w, r)
+	case "ISteamUserStats/GetNumberOfCurrentPlayers":
+		s.serveGetNumberOfCurrentPlayers(w, r)
+	case "ISteamUserAuth/AuthenticateUserTicket":
+		if s.checkPublisherKey(w, r) {
+			s.serveAuthenticateUserTicket(w, r)
+		}
+	case "ITwoFactorService/QueryTime":
+		s.serveQueryTime(w, r)
+	case "ISteamWebUserPresenceOAuth/Logon":
+		s.serveLogon(w, r)
+	case "ISteamWebUserPresenceOAuth/Poll":
+		s.servePoll(w, r)
+	case "ISteamWebUserPresenceOAuth/Message":
+		s.serveMessage(w, r)
+	default:
+		writeErrorPage(w, http.StatusNotFound, "Method not found.")
+	}
+}
+
+// checkKey answers 403 Forbidden and returns false if r does not carry the
+// Server's ApiKey.
+func (s *Server) checkKey(w http.ResponseWriter, r *http.Request) bool {
+	if s.ApiKey == "" || r.Form.Get("key") == s.ApiKey {
+		return true
+	}
+	writeErrorPage(w, http.StatusForbidden, "Access is denied. Retrying will not help. Please verify your <pre>key=</pre> parameter.")
+	return false
+}
+
+// checkPublisherKey answers 403 Forbidden and returns false if r does not
+// carry the Server's PublisherKey.
+func (s *Server) checkPublisherKey(w http.ResponseWriter, r *http.Request) bool {
+	if r.Form.Get("key") == s.PublisherKey {
+		return true
+	}
+	writeErrorPage(w, http.StatusForbidden, "Access is denied. Retrying will not help. Please verify your <pre>key=</pre> parameter.")
+	return false
+}
+
+// serveGetPlayerSummaries serves the summaries of the known players among the
+// steamids parameter.
+func (s *Server) serveGetPlayerSummaries(w http.ResponseWriter, r *http.Request) {
+	players := []map[string]interface{}{}
+
+	s.mu.Lock()
+	for _, id := range strings.Split(r.Form.Get("steamids"), ",") {
+		steamID, err := strconv.ParseUint(strings.Trim(id, `" `), 10, 64)
+		if err != nil {
+			continue
+		}
+		p, ok := s.players[steam.SteamID64(steamID)]
+		if !ok {
+			continue
+		}
+		players = append(players, s.playerSummary(p))
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"response": map[string]interface{}{"players": players},
+	})
+}
+
+// playerSummary returns the GetPlayerSummaries entry of p. Private profiles
+// only show their public fields, like on Steam.
+func (s *Server) playerSummary(p *Player) map[string]interface{} {
+	id := strconv.FormatUint(uint64(p.SteamID), 10)
+	profileURL := s.URL + "/profiles/" + id + "/"
+	if p.VanityURL != "" {
+		profileURL = s.URL + "/id/" + p.VanityURL + "/"
+	}
+	avatar := s.URL + "/avatars/" + id
+
+	summary := map[string]interface{}{
+		"steamid":                  id,
+		"communityvisibilitystate": 3,
+		"profilestate":             1,
+		"personaname":              p.PersonaName,
+		"profileurl":               profileURL,
+		"avatar":                   avatar + ".jpg",
+		"avatarmedium":             avatar + "_medium.jpg",
+		"avatarfull":               avatar + "_full.jpg",
+		"personastate":             p.PersonaState,
+		"lastlogoff":               time.Now().Add(-time.Hour).Unix(),
+	}
+	if p.Private {
+		summary["communityvisibilitystate"] = 1
+		return summary
+	}
+
+	summary["realname"] = p.RealName
+	summary["primaryclanid"] = strconv.FormatUint(uint64(p.PrimaryGroupID), 10)
+	summary["timecreated"] = p.TimeCreated
+	summary["loccountrycode"] = p.CountryCode
+	if p.GameID != 0 {
+		summary["gameid"] = strconv.Itoa(p.GameID)
+		summary["gameextrainfo"] = p.GameExtraInfo
+	}
+	return summary
+}
+
+// serveGetFriendList serves the friends list of a public player.
+func (s *Server) serveGetFriendList(w http.ResponseWriter, r *http.Request) {
+	steamID, _ := strconv.ParseUint(r.Form.Get("steamid"), 10, 64)
+
+	s.mu.Lock()
+	p, ok := s.players[steam.SteamID64(steamID)]
+	public := ok && !p.Private
+	friends := []map[string]interface{}{}
+	if public {
+		for _, friend := range p.Friends {
+			friends = append(friends, map[string]interface{}{
+				"steamid":      strconv.FormatUint(uint64(friend.SteamID), 10),
+				"relationship": "friend",
+				"friend_since": friend.FriendSince,
+			})
+		}
+	}
+	s.mu.Unlock()
+
+	if !public {
+		// Steam answers the same way for private and missing profiles.
+		writeErrorPage(w, http.StatusUnauthorized, "")
+		return
+	}
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"friendslist": map[string]interface{}{"friends": friends},
+	})
+}
+
+// serveResolveVanityURL resolves the custom URL name of a player.
+func (s *Server) serveResolveVanityURL(w http.ResponseWriter, r *http.Request) {
+	name := r.Form.Get("vanityurl")
+
+	s.mu.Lock()
+	var steamID steam.SteamID64
+	for _, p := range s.players {
+		if p.VanityURL != "" && strings.EqualFold(p.VanityURL, name) {
+			steamID = p.SteamID
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if steamID == 0 {
+		writeJSON(w, r, http.StatusOK, map[string]interface{}{
+			"response": map[string]interface{}{"success": int(steam.EResultNoMatch), "message": "No match"},
+		})
+		return
+	}
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"response": map[string]interface{}{"success": int(steam.EResultOK), "steamid": strconv.FormatUint(uint64(steamID), 10)},
+	})
+}
+
+// serveGetAppList serves the known apps in the v1 format.
+func (s *Server) serveGetAppList(w http.ResponseWriter, r *http.Request) {
+	apps := []map[string]interface{}{}
+
+	s.mu.Lock()
+	for _, app := range s.apps {
+		apps = append(apps, map[string]interface{}{"appid": app.AppID, "name": app.Name})
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"applist": map[string]interface{}{
+			"apps": map[string]interface{}{"app": apps},
+		},
+	})
+}
+
+// serveGetNumberOfCurrentPlayers serves the player count of a known app.
+func (s *Server) serveGetNumberOfCurrentPlayers(w http.ResponseWriter, r *http.Request) {
+	appID, _ := strconv.Atoi(r.Form.Get("appid"))
+
+	s.mu.Lock()
+	players, found := 0, false
+	for _, app := range s.apps {
+		if app.AppID == appID {
+			players, found = app.Players, true
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if !found {
+		writeJSON(w, r, http.StatusNotFound, map[string]interface{}{
+			"response": map[string]interface{}{"result": int(steam.EResultNoMatch)},
+		})
+		return
+	}
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"response": map[string]interface{}{"player_count": players, "result": int(steam.EResultOK)},
+	})
+}
+
+// serveQueryTime serves the server time used to align Steam Guard codes.
+func (s *Server) serveQueryTime(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"response": map[string]interface{}{
+			"server_time":                           strconv.FormatInt(s.Now().Unix(), 10),
+			"skew_tolerance_seconds":                "60",
+			"large_time_jink":                       "86400",
+			"probe_frequency_seconds":               3600,
+			"adjusted_time_probe_frequency_seconds": 300,
+			"hint_probe_frequency_seconds":          60,
+			"sync_timeout":                          60,
+			"try_again_seconds":                     900,
+			"max_attempts":                          3,
+		},
+	})
+}
+
+// serveAuthenticateUserTicket authenticates the ticket parameter for the appid
+// parameter.
+func (s *Server) serveAuthenticateUserTicket(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.Atoi(r.Form.Get("appid"))
+	ticketHex := strings.ToLower(r.Form.Get("ticket"))
+	if err != nil || ticketHex == "" {
+		writeJSON(w, r, http.StatusOK, map[string]interface{}{"response": map[string]interface{}{
+			"error": map[string]interface{}{"errorcode": 3, "errordesc": "Invalid parameter"},
+		}})
+		return
+	}
+
+	s.mu.Lock()
+	t, ok := s.tickets[ticketHex]
+	var ticket Ticket
+	if ok {
+		ticket = *t
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, r, http.StatusOK, map[string]interface{}{"response": map[string]interface{}{
+			"error": map[string]interface{}{"errorcode": 101, "errordesc": "Invalid ticket"},
+		}})
+		return
+	}
+	if ticket.AppID != appID {
+		writeJSON(w, r, http.StatusOK, map[string]interface{}{"response": map[string]interface{}{
+			"error": map[string]interface{}{"errorcode": 102, "errordesc": "Ticket for other app"},
+		}})
+		return
+	}
+
+	owner := ticket.OwnerSteamID
+	if owner == 0 {
+		owner = ticket.SteamID
+	}
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{"response": map[string]interface{}{
+		"params": map[string]interface{}{
+			"result":          "OK",
+			"steamid":         strconv.FormatUint(uint64(ticket.SteamID), 10),
+			"ownersteamid":    strconv.FormatUint(uint64(owner), 10),
+			"vacbanned":       ticket.VACBanned,
+			"publisherbanned": ticket.PublisherBanned,
+		},
+	}})
+}
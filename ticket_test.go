@@ -0,0 +1,69 @@
+package steam_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Acidic9/steam"
+	"github.com/Acidic9/steam/steamtest"
+)
+
+func TestAuthenticateUserTicket(t *testing.T) {
+	srv := steamtest.New()
+	defer srv.Close()
+	ticket := []byte{0x14, 0x00, 0x00, 0x00, 0xde, 0xad, 0xbe, 0xef}
+	srv.AddTicket(steamtest.Ticket{AppID: 440, Ticket: ticket, SteamID: botID, OwnerSteamID: friendIDs[0], VACBanned: true})
+
+	client := srv.Client()
+	client.ApiKey = steamtest.DefaultPublisherKey
+	client.Cache = steam.NewMemoryCache(100)
+
+	want := steam.UserTicket{SteamID: botID, OwnerSteamID: friendIDs[0], VACBanned: true}
+	if got, err := client.AuthenticateUserTicket(440, ticket); err != nil || got != want {
+		t.Fatalf("AuthenticateUserTicket = %+v, %v, want %+v", got, err, want)
+	}
+
+	for _, test := range []struct {
+		name    string
+		appID   int
+		ticket  []byte
+		code    int
+		invalid bool // whether the error is ErrInvalidTicket
+	}{
+		{"unknown ticket", 440, []byte{1, 2, 3}, 101, true},
+		{"other app", 570, ticket, 102, true},
+		{"no ticket", 440, nil, 3, false},
+	} {
+		_, err := client.AuthenticateUserTicket(test.appID, test.ticket)
+		var ticketErr *steam.TicketError
+		if !errors.As(err, &ticketErr) || ticketErr.Code != test.code {
+			t.Errorf("%s: error = %v, want a *TicketError with code %d", test.name, err, test.code)
+		}
+		if errors.Is(err, steam.ErrInvalidTicket) != test.invalid {
+			t.Errorf("%s: errors.Is(%v, ErrInvalidTicket) = %v, want %v", test.name, err, !test.invalid, test.invalid)
+		}
+	}
+
+	// A cancelled ticket is refused: the earlier answer was not cached.
+	before := len(srv.Requests())
+	srv.CancelTicket(ticket)
+	if _, err := client.AuthenticateUserTicket(440, ticket); !errors.Is(err, steam.ErrInvalidTicket) {
+		t.Errorf("AuthenticateUserTicket of a cancelled ticket: %v, want ErrInvalidTicket", err)
+	}
+	if n := len(srv.Requests()) - before; n != 1 {
+		t.Errorf("%d requests sent for the cancelled ticket, want 1", n)
+	}
+
+	// A Web API key which is not a publisher key is refused.
+	srv.AddTicket(steamtest.Ticket{AppID: 440, Ticket: ticket, SteamID: botID})
+	client.ApiKey = steamtest.DefaultApiKey
+	_, err := client.AuthenticateUserTicket(440, ticket)
+	var httpErr *steam.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusForbidden || !errors.Is(err, steam.ErrUnauthorized) {
+		t.Errorf("AuthenticateUserTicket without the publisher key: %v, want a 403 *HTTPError", err)
+	}
+	if errors.Is(err, steam.ErrInvalidTicket) {
+		t.Errorf("the error of a wrong key, %v, is ErrInvalidTicket", err)
+	}
+}
@@ -0,0 +1,108 @@
+package steam
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// authCodeAlphabet holds the characters of Steam Guard mobile codes.
+const authCodeAlphabet = "23456789BCDFGHJKMNPQRTVWXY"
+
+// authCodePeriod is how long a Steam Guard mobile code is valid.
+const authCodePeriod = 30 * time.Second
+
+// GenerateAuthCode returns the 5 character Steam Guard mobile code for the
+// base64 shared_secret of an authenticator at time t. t should be the Steam
+// server time, see Client.ServerTime.
+func GenerateAuthCode(sharedSecret string, t time.Time) (string, error) {
+	secret, err := base64.StdEncoding.DecodeString(sharedSecret)
+	if err != nil {
+		return "", err
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(t.Unix()/int64(authCodePeriod/time.Second)))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0F
+	fullCode := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7FFFFFFF
+
+	code := make([]byte, 5)
+	for i := range code {
+		code[i] = authCodeAlphabet[fullCode%uint32(len(authCodeAlphabet))]
+		fullCode /= uint32(len(authCodeAlphabet))
+	}
+	return string(code), nil
+}
+
+// QueryTime is a wrapper around DefaultClient.QueryTime.
+func QueryTime() (time.Time, error) {
+	return DefaultClient.QueryTime()
+}
+
+// QueryTimeContext is a wrapper around DefaultClient.QueryTimeContext.
+func QueryTimeContext(ctx context.Context) (time.Time, error) {
+	return DefaultClient.QueryTimeContext(ctx)
+}
+
+// QueryTime returns the time of the Steam servers.
+func (c *Client) QueryTime() (time.Time, error) {
+	return c.QueryTimeContext(context.Background())
+}
+
+// QueryTimeContext is like QueryTime but its requests are bound to ctx.
+func (c *Client) QueryTimeContext(ctx context.Context) (time.Time, error) {
+	req, err := c.newRequest(ctx, "POST", c.apiURL("ITwoFactorService/QueryTime/v0001", nil), url.Values{
+		"steamid": {"0"},
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	content, err := c.do(c.httpClient(), req)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var queryTimeResponse struct {
+		Response struct {
+			Server_time string
+		}
+	}
+	if err := unmarshalJSON(content, &queryTimeResponse); err != nil {
+		return time.Time{}, err
+	}
+
+	serverTime, err := strconv.ParseInt(queryTimeResponse.Response.Server_time, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(serverTime, 0), nil
+}
+
+// ServerTime returns the current Steam server time, falling back to the local
+// time if it cannot be queried. Codes generated from the local time are
+// rejected when the clock is off by more than a few seconds.
+func (c *Client) ServerTime() time.Time {
+	return c.ServerTimeContext(context.Background())
+}
+
+// ServerTimeContext is like ServerTime but its requests are bound to ctx.
+func (c *Client) ServerTimeContext(ctx context.Context) time.Time {
+	before := time.Now()
+	serverTime, err := c.QueryTimeContext(ctx)
+	if err != nil {
+		return time.Now()
+	}
+	// Apply the offset to the current time, so that the time spent waiting
+	// for the response is not lost.
+	return time.Now().Add(serverTime.Sub(before))
+}
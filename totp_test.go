@@ -0,0 +1,33 @@
+package steam
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGenerateAuthCode(t *testing.T) {
+	// The shared_secret of the bytes 1 to 20.
+	const sharedSecret = "AQIDBAUGBwgJCgsMDQ4PEBESExQ="
+	for _, test := range []struct {
+		unix int64
+		code string
+	}{
+		{0, "6KR59"},
+		{29, "6KR59"},
+		{30, "DW55B"},
+		{1700000009, "3M9KK"},
+		{1700000010, "8BXYN"}, // a 30s boundary
+		{1700000039, "8BXYN"},
+		{1700000040, "CHNV8"},
+		{2000000000, "PMG8G"},
+	} {
+		code, err := GenerateAuthCode(sharedSecret, time.Unix(test.unix, 0))
+		if err != nil || code != test.code {
+			t.Errorf("GenerateAuthCode at %d = %q, %v, want %q", test.unix, code, err, test.code)
+		}
+	}
+
+	if _, err := GenerateAuthCode("not base64!", time.Unix(0, 0)); err == nil {
+		t.Error("GenerateAuthCode of an invalid shared_secret succeeded")
+	}
+}